@@ -21,7 +21,11 @@ import (
 var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Login with EVE SSO and save token to be used by the bot",
-	Run:   runLogin,
+	Long: `Login with EVE SSO and save token to be used by the bot.
+Run it once for every character whose corporation should be monitored,
+tokens are added to the auth file. Logging in with the same character again
+replaces its token.`,
+	Run: runLogin,
 }
 
 func init() {
@@ -46,13 +50,6 @@ func runLogin(cmd *cobra.Command, args []string) {
 	// Notify signalChan on SIGINT and SIGTERM.
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
-	if _, err := os.Stat(authfile); !os.IsNotExist(err) {
-		err = os.Remove(authfile)
-		if err != nil {
-			panic(fmt.Sprintf("unable to delete file: %s please remove it by hand", authfile))
-		}
-	}
-
 	handler := handler.New(
 		signalChan,
 		log,
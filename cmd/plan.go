@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/bot"
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/token"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Print fuel needed to top up structures",
+	Long: `Print how many fuel blocks each structure needs to have fuel for --days,
+capped by its fuel bay capacity, with total volume, cost and a list to paste
+into the in-game multibuy window.`,
+	Run: runPlan,
+}
+
+var planDays int // days of fuel to top up structures to
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().StringVarP(&authfile, "auth_file", "a", "auth.bin", "path to file where authentication data is saved")
+	planCmd.Flags().StringVarP(&sessionKey, "session_key", "s", "", "session key, use random string")
+	planCmd.Flags().StringVar(&eveClientID, "eve_client_id", "", "EVE APP client id")
+	planCmd.Flags().StringVar(&eveSSOSecret, "eve_sso_secret", "", "EVE APP SSO secret")
+	planCmd.Flags().IntVar(&planDays, "days", 30, "days of fuel to top up structures to")
+	addPriceFlags(planCmd)
+
+	must(planCmd.MarkFlagRequired("session_key"))
+	must(planCmd.MarkFlagRequired("eve_client_id"))
+	must(planCmd.MarkFlagRequired("eve_sso_secret"))
+}
+
+func runPlan(cmd *cobra.Command, args []string) {
+	fastLog, err := zap.NewDevelopment()
+	if err != nil {
+		panic(fmt.Sprintf("error inicializing logger: %s", err))
+	}
+	log := fastLog.Sugar()
+
+	client := httpClient()
+
+	tokenStorage := token.NewFileStorage(authfile)
+	tokenSources, err := token.NewSources(log, client, tokenStorage, []byte(sessionKey), eveClientID, eveSSOSecret, eveCallbackURL, eveScopes)
+	if err != nil {
+		panic(fmt.Sprintf("error loading tokens from %s: %s", authfile, err))
+	}
+
+	prices, err := priceProvider(client)
+	if err != nil {
+		panic(fmt.Sprintf("error creating price provider: %s", err))
+	}
+	priceCache := price.NewCache(log, prices, price.NewFileStorage(priceCacheFile), priceInterval)
+	if time.Since(priceCache.Snapshot().UpdatedAt) > priceInterval {
+		err = priceCache.Refresh(bot.PriceTypeIDs())
+		if err != nil {
+			// Log but continue, plan is useful without prices.
+			log.Errorw("Error refreshing prices", "error", err)
+		}
+	}
+	sdeData, err := loadSDE()
+	if err != nil {
+		panic(fmt.Sprintf("error loading structure data: %s", err))
+	}
+
+	plan, err := bot.NewPlanner(log, client, tokenSources, priceCache, sdeData).Plan(planDays)
+	if err != nil {
+		panic(fmt.Sprintf("error planning fuel: %s", err))
+	}
+	fmt.Print(plan)
+}
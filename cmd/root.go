@@ -25,13 +25,14 @@ const (
 
 // variables parsed from CLI.
 var (
+	cfgFile      string // path to config file
 	authfile     string // path to file with authentication data
 	sessionKey   string // session key used for user session encryption
 	eveClientID  string // EVE APP Client ID
 	eveSSOSecret string // EVE APP SSO secret
 )
 
-var eveScopes = []string{"publicData", "esi-universe.read_structures.v1", "esi-corporations.read_structures.v1"}
+var eveScopes = []string{"publicData", "esi-universe.read_structures.v1", "esi-corporations.read_structures.v1", "esi-assets.read_corporation_assets.v1", "esi-corporations.read_starbases.v1", "esi-industry.read_corporation_mining.v1", "esi-characters.read_notifications.v1"}
 
 func httpClient() *http.Client {
 	transport := httpcache.NewTransport(httpcache.NewMemoryCache())
@@ -54,15 +55,28 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is fuelbot.yaml in current directory)")
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	if cfgFile != "" {
+		viper.SetConfigFile(cfgFile)
+	} else {
+		viper.SetConfigName("fuelbot")
+		viper.AddConfigPath(".")
+	}
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	err := viper.ReadInConfig()
+	switch {
+	case err == nil:
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	case cfgFile != "":
+		// Config file was requested explicitly, do not run without it.
+		fmt.Println("Unable to read config file:", err)
+		os.Exit(1)
 	}
 }
 
@@ -2,13 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/lunemec/eve-fuelbot/pkg/bot"
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
 	"github.com/lunemec/eve-fuelbot/pkg/token"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
@@ -21,32 +28,93 @@ var runCmd = &cobra.Command{
 
 var (
 	checkInterval      time.Duration
+	refuelDetection    time.Duration
 	notifyInterval     time.Duration
 	refuelNotification time.Duration
 
-	discordChannelID string
-	discordAuthToken string
+	statefile    string // path to file with bot state
+	deferredFile string // path to file with messages deferred by quiet hours
+
+	discordChannelID      string
+	discordAuthToken      string
+	discordMessageContent bool
+
+	priceProviderName string  // esi, fuzzwork or static
+	priceRegionID     int32   // region to read market prices from
+	priceStationID    int64   // station to read market prices from, 0 for whole region
+	pricePercentile   float64 // percentile of cheapest sell volume for esi price provider
+	priceFile         string  // path to file with static prices
+	priceCacheFile    string  // path to file with last known prices
+	priceInterval     time.Duration
+
+	sdeDir string // path to unpacked SDE, bundled snapshot is used when empty
+
+	stockDays     int    // days of fuel hangar stock has to cover
+	stockCoverage string // system or region
+	stockInterval time.Duration
+
+	ozoneMinimum  int64 // liquid ozone in jump gate fuel bay to warn below
+	ozoneInterval time.Duration
+
+	strontiumMinimum  time.Duration // reinforcement starbase strontium has to last
+	strontiumInterval time.Duration
+
+	extractionNotification time.Duration
+
+	notificationTypes []string // in-game notification types to forward
+
+	claimDuration time.Duration
+
+	statusChannels []string // channels to keep pinned status board in
 )
 
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringVarP(&authfile, "auth_file", "a", "auth.bin", "path to file where to save authentication data")
+	runCmd.Flags().StringVar(&statefile, "state_file", "state.bin", "path to file where to save bot state (notified structures)")
+	runCmd.Flags().StringVar(&deferredFile, "deferred_file", "deferred.bin", "path to file where to save alerts held back by quiet hours")
 	runCmd.Flags().StringVarP(&sessionKey, "session_key", "s", "", "session key, use random string")
 	runCmd.Flags().StringVar(&eveClientID, "eve_client_id", "", "EVE APP client id")
 	runCmd.Flags().StringVar(&eveSSOSecret, "eve_sso_secret", "", "EVE APP SSO secret")
-	runCmd.Flags().StringVar(&discordChannelID, "discord_channel_id", "", "ID of discord channel")
+	runCmd.Flags().StringVar(&discordChannelID, "discord_channel_id", "", "ID of discord channel to send alerts to, not needed when notifiers are configured")
 	runCmd.Flags().StringVar(&discordAuthToken, "discord_auth_token", "", "Auth token for discord")
+	runCmd.Flags().BoolVar(&discordMessageContent, "discord_message_content", false, "request privileged Message Content intent needed for opt-in \"!fuel\" text command, slash commands work without it")
 	runCmd.Flags().DurationVar(&checkInterval, "check_interval", 1*time.Hour, "how often to check EVE ESI API (default 1H)")
-	runCmd.Flags().DurationVar(&notifyInterval, "notify_interval", 12*time.Hour, "how often to spam discord (default 12H)")
-	runCmd.Flags().DurationVar(&refuelNotification, "refuel_notification", 5*24*time.Hour, "how far in advance would you like to be notified about the fuel (default 5 days)")
+	runCmd.Flags().DurationVar(&refuelDetection, "refuel_detection", 1*time.Hour, "how much fuel expiration has to move forward to report structure as refuelled (default 1H)")
+	runCmd.Flags().DurationVar(&notifyInterval, "notify_interval", 12*time.Hour, "how often to spam discord (default 12H), ignored when thresholds are configured")
+	runCmd.Flags().DurationVar(&refuelNotification, "refuel_notification", 5*24*time.Hour, "how far in advance would you like to be notified about the fuel (default 5 days), ignored when thresholds are configured")
+	addPriceFlags(runCmd)
+	runCmd.Flags().IntVar(&stockDays, "stock_days", 0, "warn when fuel blocks in corporation hangars cover fewer days of structure fuel consumption (default 0 disabled)")
+	runCmd.Flags().StringVar(&stockCoverage, "stock_coverage", bot.StockCoverageSystem, "which structures hangar stock covers: system or region")
+	runCmd.Flags().DurationVar(&stockInterval, "stock_interval", 24*time.Hour, "how often to repeat fuel stock warning (default 24H)")
+	runCmd.Flags().Int64Var(&ozoneMinimum, "ozone_minimum", 0, "warn when Ansiblex jump gate has less liquid ozone in fuel bay (default 0 disabled)")
+	runCmd.Flags().DurationVar(&ozoneInterval, "ozone_interval", 12*time.Hour, "how often to repeat liquid ozone warning (default 12H)")
+	runCmd.Flags().DurationVar(&strontiumMinimum, "strontium_minimum", 0, "warn when strontium in POS lasts shorter reinforcement (default 0 disabled)")
+	runCmd.Flags().DurationVar(&strontiumInterval, "strontium_interval", 12*time.Hour, "how often to repeat strontium warning (default 12H)")
+	runCmd.Flags().DurationVar(&extractionNotification, "extraction_notification", 3*time.Hour, "how far in advance to notify about moon chunk arrival (default 3H), 0 disables it")
+	runCmd.Flags().StringSliceVar(&notificationTypes, "notification_types", bot.DefaultNotificationTypes, "in-game notification types to forward, empty to disable")
+	runCmd.Flags().DurationVar(&claimDuration, "claim_duration", 12*time.Hour, "how long \"I'm on it\" alert button pauses alerts of the structure (default 12H)")
+	runCmd.Flags().StringSliceVar(&statusChannels, "status_channels", nil, "IDs of discord channels to keep pinned status board in, updated every check_interval")
 
 	must(runCmd.MarkFlagRequired("session_key"))
 	must(runCmd.MarkFlagRequired("eve_client_id"))
 	must(runCmd.MarkFlagRequired("eve_sso_secret"))
-	must(runCmd.MarkFlagRequired("discord_channel_id"))
 	must(runCmd.MarkFlagRequired("discord_auth_token"))
 }
 
+// addPriceFlags adds flags of fuel price provider and structure data,
+// shared by commands which compute fuel.
+func addPriceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&priceProviderName, "price_provider", "esi", "where to get fuel prices from: esi, fuzzwork or static")
+	cmd.Flags().Int32Var(&priceRegionID, "price_region", price.TheForgeRegionID, "region ID to get fuel prices from (default The Forge)")
+	cmd.Flags().Int64Var(&priceStationID, "price_station", 0, fmt.Sprintf("station ID to get fuel prices from, eg. %d for Jita 4-4 (default 0 for whole region)", price.JitaStationID))
+	cmd.Flags().Float64Var(&pricePercentile, "price_percentile", 5, "percentile of the cheapest sell volume to average for esi price provider")
+	cmd.Flags().StringVar(&priceFile, "price_file", "prices.yaml", "path to file with type ID to price mapping for static price provider")
+	cmd.Flags().StringVar(&priceCacheFile, "price_cache_file", "prices.bin", "path to file where to save last known fuel prices")
+	cmd.Flags().DurationVar(&priceInterval, "price_interval", 1*time.Hour, "how often to refresh fuel prices (default 1H)")
+	cmd.Flags().StringVar(&sdeDir, "sde_dir", "", "path to unpacked EVE SDE to load structure and service fuel data from (default bundled snapshot)")
+}
+
 func runBot(cmd *cobra.Command, args []string) {
 	fastLog, err := zap.NewDevelopment()
 	if err != nil {
@@ -57,17 +125,218 @@ func runBot(cmd *cobra.Command, args []string) {
 	client := httpClient()
 
 	tokenStorage := token.NewFileStorage(authfile)
-	tokenSource := token.NewSource(log, client, tokenStorage, []byte(sessionKey), eveClientID, eveSSOSecret, eveCallbackURL, eveScopes)
+	tokenSources, err := token.NewSources(log, client, tokenStorage, []byte(sessionKey), eveClientID, eveSSOSecret, eveCallbackURL, eveScopes)
+	if err != nil {
+		panic(fmt.Sprintf("error loading tokens from %s: %s", authfile, err))
+	}
 
 	discord, err := discordgo.New("Bot " + discordAuthToken)
 	if err != nil {
 		panic(fmt.Sprintf("error inicializing discord client: %s", err))
 	}
-	discord.Identify.Intents |= discordgo.IntentMessageContent
-	bot := bot.NewFuelBot(log, client, tokenSource, discord, discordChannelID, checkInterval, notifyInterval, refuelNotification)
+	if discordMessageContent {
+		discord.Identify.Intents |= discordgo.IntentMessageContent
+	}
+	stateStorage := state.NewFileStorage(statefile)
+
+	thresholds, err := loadThresholds()
+	if err != nil {
+		panic(fmt.Sprintf("error loading thresholds: %s", err))
+	}
+	prices, err := priceProvider(client)
+	if err != nil {
+		panic(fmt.Sprintf("error creating price provider: %s", err))
+	}
+	priceCache := price.NewCache(log, prices, price.NewFileStorage(priceCacheFile), priceInterval)
+	sdeData, err := loadSDE()
+	if err != nil {
+		panic(fmt.Sprintf("error loading structure data: %s", err))
+	}
+	if stockCoverage != bot.StockCoverageSystem && stockCoverage != bot.StockCoverageRegion {
+		panic(fmt.Sprintf("unknown stock coverage: %s", stockCoverage))
+	}
+	deferred, err := notify.NewQueue(notify.NewFileQueueStorage(deferredFile))
+	if err != nil {
+		panic(fmt.Sprintf("error loading deferred alerts: %s", err))
+	}
+	notifier, notifiers, err := loadNotifiers(client, discord, deferred)
+	if err != nil {
+		panic(fmt.Sprintf("error loading notifiers: %s", err))
+	}
+	routes, err := loadRoutes(notifiers)
+	if err != nil {
+		panic(fmt.Sprintf("error loading routes: %s", err))
+	}
+	escalations, err := loadEscalations(notifiers)
+	if err != nil {
+		panic(fmt.Sprintf("error loading escalation: %s", err))
+	}
+	digest, err := loadDigest(notifiers)
+	if err != nil {
+		panic(fmt.Sprintf("error loading digest: %s", err))
+	}
+	cfg := bot.Config{
+		CheckInterval:   checkInterval,
+		RefuelDetection: refuelDetection,
+		Thresholds:      thresholds,
+		Routes:          routes,
+		Escalations:     escalations,
+		Stock: bot.StockConfig{
+			Days:     stockDays,
+			Coverage: stockCoverage,
+			Interval: stockInterval,
+		},
+		Ozone: bot.OzoneConfig{
+			Minimum:  ozoneMinimum,
+			Interval: ozoneInterval,
+		},
+		Strontium: bot.StrontiumConfig{
+			Minimum:  strontiumMinimum,
+			Interval: strontiumInterval,
+		},
+		Extraction: bot.ExtractionConfig{
+			NotifyBefore: extractionNotification,
+		},
+		Notification: bot.NotificationConfig{
+			Types: notificationTypes,
+		},
+		Ack: bot.AckConfig{
+			Claim: claimDuration,
+		},
+		Board: bot.BoardConfig{
+			ChannelIDs: statusChannels,
+		},
+		Digest:   digest,
+		Deferred: deferred,
+	}
+	bot := bot.NewFuelBot(log, client, tokenSources, stateStorage, priceCache, sdeData, discord, notifier, cfg)
 	err = bot.Bot()
 	// systemd handles reload, so we can panic on error.
 	if err != nil {
 		panic(err)
 	}
 }
+
+// loadThresholds reads notification thresholds from config file. When
+// there are none, single threshold from refuel_notification and
+// notify_interval flags is used.
+func loadThresholds() (bot.Thresholds, error) {
+	var thresholds []bot.Threshold
+	err := viper.UnmarshalKey("thresholds", &thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if len(thresholds) == 0 {
+		thresholds = []bot.Threshold{
+			{
+				Before:   refuelNotification,
+				Interval: notifyInterval,
+				Color:    0xff0000,
+			},
+		}
+	}
+	return bot.NewThresholds(thresholds)
+}
+
+// loadNotifiers reads notifiers alerts are sent through from config
+// file and returns the default one, and named ones for routes. When
+// there are none, alerts are sent to discord_channel_id. Alerts not
+// matching any route go to default_notifiers, or to all notifiers.
+func loadNotifiers(client *http.Client, discord *discordgo.Session, deferred *notify.Queue) (notify.Notifier, map[string]notify.Notifier, error) {
+	var configs []notify.Config
+	err := viper.UnmarshalKey("notifiers", &configs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(configs) == 0 {
+		if discordChannelID == "" {
+			return nil, nil, errors.New("discord_channel_id or notifiers in config file are required")
+		}
+		return notify.NewDiscord(discord, discordChannelID), nil, nil
+	}
+
+	var (
+		notifiers []notify.Notifier
+		named     = make(map[string]notify.Notifier)
+	)
+	for i, cfg := range configs {
+		notifier, err := notify.New(cfg, client, discord, deferred)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "notifier %d", i)
+		}
+		notifiers = append(notifiers, notifier)
+		if cfg.Name != "" {
+			if _, ok := named[cfg.Name]; ok {
+				return nil, nil, errors.Errorf("duplicate notifier name: %s", cfg.Name)
+			}
+			named[cfg.Name] = notifier
+		}
+	}
+
+	defaults := viper.GetStringSlice("default_notifiers")
+	if len(defaults) == 0 {
+		return notify.NewMulti(notifiers...), named, nil
+	}
+	notifiers = nil
+	for _, name := range defaults {
+		notifier, ok := named[name]
+		if !ok {
+			return nil, nil, errors.Errorf("unknown default notifier: %s", name)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notify.NewMulti(notifiers...), named, nil
+}
+
+// loadRoutes reads alert routes from config file.
+func loadRoutes(notifiers map[string]notify.Notifier) ([]bot.Route, error) {
+	var configs []bot.RouteConfig
+	err := viper.UnmarshalKey("routes", &configs)
+	if err != nil {
+		return nil, err
+	}
+	return bot.NewRoutes(configs, notifiers)
+}
+
+// loadEscalations reads escalation chain of unresolved fuel alerts from
+// config file.
+func loadEscalations(notifiers map[string]notify.Notifier) ([]bot.Escalation, error) {
+	var configs []bot.EscalationConfig
+	err := viper.UnmarshalKey("escalation", &configs)
+	if err != nil {
+		return nil, err
+	}
+	return bot.NewEscalations(configs, notifiers)
+}
+
+// loadDigest reads scheduled fuel digest from config file.
+func loadDigest(notifiers map[string]notify.Notifier) (bot.Digest, error) {
+	var cfg bot.DigestConfig
+	err := viper.UnmarshalKey("digest", &cfg)
+	if err != nil {
+		return bot.Digest{}, err
+	}
+	return bot.NewDigest(cfg, notifiers)
+}
+
+// priceProvider returns fuel price provider selected by price_provider flag.
+func priceProvider(client *http.Client) (price.Provider, error) {
+	switch priceProviderName {
+	case "esi":
+		return price.NewESIProvider(client, priceRegionID, priceStationID, pricePercentile), nil
+	case "fuzzwork":
+		return price.NewFuzzworkProvider(client, price.FuzzworkAggregatesURL, priceRegionID, priceStationID), nil
+	case "static":
+		return price.NewStaticProvider(priceFile), nil
+	}
+	return nil, errors.Errorf("unknown price provider: %s", priceProviderName)
+}
+
+// loadSDE returns structure and service data from SDE when sde_dir
+// is set, bundled snapshot otherwise.
+func loadSDE() (*sde.Data, error) {
+	if sdeDir != "" {
+		return sde.Load(sdeDir)
+	}
+	return sde.Default()
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
+	"github.com/spf13/cobra"
+)
+
+// sdeCmd represents the sde command
+var sdeCmd = &cobra.Command{
+	Use:   "sde",
+	Short: "Build structure and service fuel data snapshot from EVE SDE",
+	Long: `Build structure and service fuel data snapshot from unpacked EVE SDE
+(Static Data Export), download it from https://developers.eveonline.com/resource.
+Writing to pkg/sde/snapshot.yaml refreshes the snapshot bundled with the bot.`,
+	Run: runSDE,
+}
+
+var sdeOut string // path to write the snapshot to
+
+func init() {
+	rootCmd.AddCommand(sdeCmd)
+	sdeCmd.Flags().StringVar(&sdeDir, "sde_dir", "", "path to unpacked EVE SDE")
+	sdeCmd.Flags().StringVar(&sdeOut, "out", "pkg/sde/snapshot.yaml", "path to write the snapshot to")
+
+	must(sdeCmd.MarkFlagRequired("sde_dir"))
+}
+
+func runSDE(cmd *cobra.Command, args []string) {
+	data, err := sde.Load(sdeDir)
+	if err != nil {
+		panic(fmt.Sprintf("error loading sde: %s", err))
+	}
+	err = sde.WriteFile(sdeOut, data)
+	if err != nil {
+		panic(fmt.Sprintf("error writing snapshot: %s", err))
+	}
+	fmt.Printf("Written %d structures to %s\n", len(data.Structures), sdeOut)
+}
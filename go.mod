@@ -1,6 +1,6 @@
 module github.com/lunemec/eve-fuelbot
 
-go 1.12
+go 1.16
 
 require (
 	github.com/antihax/goesi v0.0.0-20230122031109-2c5587c28863
@@ -28,4 +28,5 @@ require (
 	golang.org/x/crypto v0.7.0 // indirect
 	golang.org/x/oauth2 v0.6.0
 	google.golang.org/protobuf v1.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
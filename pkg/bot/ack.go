@@ -0,0 +1,237 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// Alert button actions.
+const (
+	ackClaim    = "claim"
+	ackSnooze   = "snooze"
+	ackIgnore   = "ignore"
+	ackUnignore = "unignore"
+
+	// ackPrefix starts custom ID of alert buttons, eg. "ack:snooze:1234:6".
+	ackPrefix = "ack"
+)
+
+var snoozeDurations = []time.Duration{6 * time.Hour, 24 * time.Hour}
+
+// AckConfig configures alert buttons.
+type AckConfig struct {
+	// Claim is how long "I'm on it" suppresses alerts.
+	Claim time.Duration
+}
+
+// ackButton is action of alert button about structure or starbase ID,
+// encoded in button custom ID.
+type ackButton struct {
+	Action string
+	ID     int64
+	// Duration of snooze.
+	Duration time.Duration
+}
+
+func (a ackButton) CustomID() string {
+	id := fmt.Sprintf("%s:%s:%d", ackPrefix, a.Action, a.ID)
+	if a.Action == ackSnooze {
+		id = fmt.Sprintf("%s:%d", id, int(a.Duration.Hours()))
+	}
+	return id
+}
+
+func parseAckButton(customID string) (ackButton, error) {
+	parts := strings.Split(customID, ":")
+	if len(parts) < 3 || parts[0] != ackPrefix {
+		return ackButton{}, errors.Errorf("invalid alert button: %s", customID)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ackButton{}, errors.Wrapf(err, "invalid alert button: %s", customID)
+	}
+	button := ackButton{
+		Action: parts[1],
+		ID:     id,
+	}
+	switch button.Action {
+	case ackClaim, ackIgnore, ackUnignore:
+		if len(parts) != 3 {
+			return ackButton{}, errors.Errorf("invalid alert button: %s", customID)
+		}
+	case ackSnooze:
+		if len(parts) != 4 {
+			return ackButton{}, errors.Errorf("invalid alert button: %s", customID)
+		}
+		hours, err := strconv.Atoi(parts[3])
+		if err != nil || hours <= 0 {
+			return ackButton{}, errors.Errorf("invalid snooze of alert button: %s", customID)
+		}
+		button.Duration = time.Duration(hours) * time.Hour
+	default:
+		return ackButton{}, errors.Errorf("unknown alert button action: %s", customID)
+	}
+	return button, nil
+}
+
+// ackComponents returns buttons to claim, snooze or ignore alerts of
+// structure or starbase with id.
+func ackComponents(id int64) []discordgo.MessageComponent {
+	if id == 0 {
+		return nil
+	}
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "I'm on it",
+			Style:    discordgo.SuccessButton,
+			CustomID: ackButton{Action: ackClaim, ID: id}.CustomID(),
+		},
+	}
+	for _, d := range snoozeDurations {
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Snooze %dh", int(d.Hours())),
+			Style:    discordgo.SecondaryButton,
+			CustomID: ackButton{Action: ackSnooze, ID: id, Duration: d}.CustomID(),
+		})
+	}
+	buttons = append(buttons, discordgo.Button{
+		Label:    "Ignore this structure",
+		Style:    discordgo.DangerButton,
+		CustomID: ackButton{Action: ackIgnore, ID: id}.CustomID(),
+	})
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// sendAlert sends alert about target within threshold with buttons to
+// acknowledge it.
+func (b *fuelBot) sendAlert(t target, threshold Threshold, embed *discordgo.MessageEmbed) error {
+	return b.sendMessage(t, notify.Message{
+		Content:    threshold.Mention,
+		Embed:      embed,
+		Components: ackComponents(t.ID),
+		Critical:   threshold.Critical,
+	})
+}
+
+// acked returns acknowledgement of alerts of structure or starbase with
+// id, when it still suppresses them.
+func (b *fuelBot) acked(id int64) (state.Ack, bool) {
+	b.stateMu.Lock()
+	ack, ok := b.state.Acks[id]
+	b.stateMu.Unlock()
+	return ack, ok && ackActive(ack, time.Now())
+}
+
+func ackActive(ack state.Ack, now time.Time) bool {
+	return ack.Until.IsZero() || now.Before(ack.Until)
+}
+
+// ackHandler records which alert button was pressed by whom and tells
+// the channel about it.
+func (b *fuelBot) ackHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	button, err := parseAckButton(i.MessageComponentData().CustomID)
+	if err != nil {
+		b.log.Errorw("error handling alert button", "err", err)
+		return
+	}
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	name := b.loadedTarget(button.ID).Name
+	if name == "" {
+		name = strconv.FormatInt(button.ID, 10)
+	}
+
+	var (
+		ack = state.Ack{
+			Action: button.Action,
+			By:     user.Username,
+			At:     time.Now(),
+		}
+		content    string
+		components []discordgo.MessageComponent
+	)
+	switch button.Action {
+	case ackClaim:
+		ack.Until = ack.At.Add(b.cfg.Ack.Claim)
+		content = fmt.Sprintf("<@%s> is on it, `%s` alerts resume `%s`.", user.ID, name, humanize.Time(ack.Until))
+	case ackSnooze:
+		ack.Until = ack.At.Add(button.Duration)
+		content = fmt.Sprintf("<@%s> snoozed `%s` alerts, they resume `%s`.", user.ID, name, humanize.Time(ack.Until))
+	case ackIgnore:
+		content = fmt.Sprintf("<@%s> ignored `%s`, no more alerts about it.", user.ID, name)
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Stop ignoring",
+						Style:    discordgo.SecondaryButton,
+						CustomID: ackButton{Action: ackUnignore, ID: button.ID}.CustomID(),
+					},
+				},
+			},
+		}
+	case ackUnignore:
+		content = fmt.Sprintf("<@%s> stopped ignoring `%s`.", user.ID, name)
+	}
+
+	b.stateMu.Lock()
+	if button.Action == ackUnignore {
+		// Only ignore is removed, claim or snooze made since stays.
+		if b.state.Acks[button.ID].Action == ackIgnore {
+			delete(b.state.Acks, button.ID)
+		}
+	} else {
+		b.state.Acks[button.ID] = ack
+	}
+	b.saveState()
+	b.stateMu.Unlock()
+
+	b.log.Infow("Alert button pressed",
+		"id", button.ID,
+		"action", button.Action,
+		"user", user.Username,
+	)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+			// Tell who pressed the button without pinging them.
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		b.log.Errorw("error responding to alert button", "err", err)
+	}
+}
+
+// formatAck returns claim, snooze or ignore of structure or starbase
+// alerts for "!fuel", empty when there is none.
+func (b *fuelBot) formatAck(id int64) string {
+	ack, ok := b.acked(id)
+	if !ok {
+		return ""
+	}
+	switch ack.Action {
+	case ackClaim:
+		return fmt.Sprintf("\n **Claimed** by `%s`, alerts resume `%s`", ack.By, humanize.Time(ack.Until))
+	case ackSnooze:
+		return fmt.Sprintf("\n **Snoozed** by `%s`, alerts resume `%s`", ack.By, humanize.Time(ack.Until))
+	case ackIgnore:
+		return fmt.Sprintf("\n **Ignored** by `%s`", ack.By)
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+)
+
+func TestAckButton(t *testing.T) {
+	buttons := []ackButton{
+		{Action: ackClaim, ID: 1021},
+		{Action: ackSnooze, ID: 1021, Duration: 6 * time.Hour},
+		{Action: ackIgnore, ID: 1021},
+		{Action: ackUnignore, ID: 1021},
+	}
+	for _, button := range buttons {
+		parsed, err := parseAckButton(button.CustomID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != button {
+			t.Errorf("parseAckButton(%q) = %+v, expected %+v", button.CustomID(), parsed, button)
+		}
+	}
+
+	for _, customID := range []string{"ack:claim", "ack:snooze:1021", "ack:snooze:1021:0", "ack:feed:1021", "other:claim:1021"} {
+		_, err := parseAckButton(customID)
+		if err == nil {
+			t.Errorf("parseAckButton(%q) expected error", customID)
+		}
+	}
+}
+
+func TestAckActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		ack    state.Ack
+		active bool
+	}{
+		{ack: state.Ack{Action: ackIgnore}, active: true},
+		{ack: state.Ack{Action: ackClaim, Until: now.Add(time.Hour)}, active: true},
+		{ack: state.Ack{Action: ackSnooze, Until: now.Add(-time.Hour)}, active: false},
+	}
+	for _, test := range tests {
+		if active := ackActive(test.ack, now); active != test.active {
+			t.Errorf("ackActive(%+v) = %t, expected %t", test.ack, active, test.active)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/lunemec/eve-fuelbot/pkg/esipage"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/pkg/errors"
+)
+
+// locationFlagStructureFuel is location flag of items in structure fuel bay.
+const locationFlagStructureFuel = "StructureFuel"
+
+// locationFlagRigSlot is prefix of location flags of fitted rigs, eg.
+// "RigSlot0".
+const locationFlagRigSlot = "RigSlot"
+
+type asset = esi.GetCorporationsCorporationIdAssets200Ok
+
+// loadAssets loads all corporation assets, this needs
+// esi-assets.read_corporation_assets.v1 scope and Director role.
+func (b *fuelBot) loadAssets(corp corporation) ([]asset, error) {
+	var (
+		out   []asset
+		page  int32 = 1
+		pages int32 = 1
+	)
+	for ; page <= pages; page++ {
+		assets, resp, err := b.esi.ESI.AssetsApi.GetCorporationsCorporationIdAssets(corp.ctx(), corp.ID, &esi.GetCorporationsCorporationIdAssetsOpts{
+			Page: optional.NewInt32(page),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read corporation assets page: %d", page)
+		}
+		pages = esipage.Count(resp)
+		out = append(out, assets...)
+	}
+	return out, nil
+}
+
+// fuelBays returns quantity of each item type in fuel bay of each structure.
+func fuelBays(assets []asset) map[int64]map[int32]int64 {
+	out := make(map[int64]map[int32]int64)
+	for _, asset := range assets {
+		if asset.LocationFlag != locationFlagStructureFuel {
+			continue
+		}
+		bay, ok := out[asset.LocationId]
+		if !ok {
+			bay = make(map[int32]int64)
+			out[asset.LocationId] = bay
+		}
+		bay[asset.TypeId] += int64(asset.Quantity)
+	}
+	return out
+}
+
+// fittedRigs returns type IDs of rigs fitted to each structure.
+func fittedRigs(assets []asset) map[int64][]int32 {
+	out := make(map[int64][]int32)
+	for _, asset := range assets {
+		if !strings.HasPrefix(asset.LocationFlag, locationFlagRigSlot) {
+			continue
+		}
+		out[asset.LocationId] = append(out[asset.LocationId], asset.TypeId)
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// BoardConfig configures pinned status boards.
+type BoardConfig struct {
+	// ChannelIDs to keep status board in.
+	ChannelIDs []string
+}
+
+// updateBoards edits status board in each configured channel to show
+// current fuel status, boards which are missing are posted and pinned.
+func (b *fuelBot) updateBoards(structs []structureData) {
+	starbases := b.loadedStarbases()
+	if len(structs) == 0 && len(starbases) == 0 {
+		// Keep the last status when structures failed to load.
+		return
+	}
+	for _, channelID := range b.cfg.Board.ChannelIDs {
+		embeds := b.allStructuresMessage(structs, starbases)
+
+		b.stateMu.Lock()
+		messageIDs := b.state.Boards[channelID]
+		b.stateMu.Unlock()
+
+		messageIDs, err := b.updateBoard(b.discord, channelID, messageIDs, embeds)
+		if err != nil {
+			b.log.Errorw("Error updating status board",
+				"channel_id", channelID,
+				"error", err,
+			)
+		}
+
+		b.stateMu.Lock()
+		b.state.Boards[channelID] = messageIDs
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+// boardSession is part of discord session status boards need.
+type boardSession interface {
+	ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+	ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error
+}
+
+// updateBoard edits board messageIDs in channel to show embeds, one
+// message per embed, and returns IDs of the board messages. When any of
+// them was deleted, the whole board is posted again to keep the order.
+// Messages somebody unpinned are pinned again.
+func (b *fuelBot) updateBoard(s boardSession, channelID string, messageIDs []string, embeds []*discordgo.MessageEmbed) ([]string, error) {
+	if len(messageIDs) == len(embeds) {
+		var (
+			msg *discordgo.Message
+			err error
+		)
+		for i, embed := range embeds {
+			msg, err = s.ChannelMessageEditEmbed(channelID, messageIDs[i], embed)
+			if err != nil {
+				break
+			}
+			if !msg.Pinned {
+				b.pinBoard(s, channelID, msg.ID)
+			}
+		}
+		if err == nil {
+			return messageIDs, nil
+		}
+		if !notFound(err) {
+			// Board is still there, try again next time.
+			return messageIDs, errors.Wrap(err, "error editing status board")
+		}
+	}
+
+	// Number of messages changed or some were deleted, post it again.
+	for _, messageID := range messageIDs {
+		// Deleted messages were already gone.
+		_ = s.ChannelMessageDelete(channelID, messageID)
+	}
+	var posted []string
+	for _, embed := range embeds {
+		msg, err := s.ChannelMessageSendEmbed(channelID, embed)
+		if err != nil {
+			return posted, errors.Wrap(err, "error posting status board")
+		}
+		posted = append(posted, msg.ID)
+		b.pinBoard(s, channelID, msg.ID)
+	}
+	return posted, nil
+}
+
+// pinBoard pins board message, failure is only logged as pinning needs
+// Manage Messages permission and the board works without it.
+func (b *fuelBot) pinBoard(s boardSession, channelID, messageID string) {
+	err := s.ChannelMessagePin(channelID, messageID)
+	if err != nil {
+		b.log.Errorw("Error pinning status board",
+			"channel_id", channelID,
+			"message_id", messageID,
+			"error", err,
+		)
+	}
+}
+
+// notFound checks if discord error is about missing message.
+func notFound(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	return ok && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
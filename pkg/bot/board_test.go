@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+func TestNotFound(t *testing.T) {
+	tests := []struct {
+		err      error
+		notFound bool
+	}{
+		{err: &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}, notFound: true},
+		{err: &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}, notFound: false},
+		{err: errors.New("connection reset"), notFound: false},
+	}
+	for _, test := range tests {
+		if notFound(test.err) != test.notFound {
+			t.Errorf("notFound(%v) = %t, expected %t", test.err, !test.notFound, test.notFound)
+		}
+	}
+}
+
+// fakeBoardSession keeps channel messages in memory.
+type fakeBoardSession struct {
+	messages map[string]*discordgo.Message
+	nextID   int
+	// editErr is returned by edits instead of editing.
+	editErr error
+	edits   int
+	deleted []string
+}
+
+func newFakeBoardSession() *fakeBoardSession {
+	return &fakeBoardSession{messages: make(map[string]*discordgo.Message)}
+}
+
+func (f *fakeBoardSession) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.editErr != nil {
+		return nil, f.editErr
+	}
+	msg, ok := f.messages[messageID]
+	if !ok {
+		return nil, &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	}
+	f.edits++
+	msg.Embeds = []*discordgo.MessageEmbed{embed}
+	return msg, nil
+}
+
+func (f *fakeBoardSession) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.nextID++
+	msg := &discordgo.Message{ID: strconv.Itoa(f.nextID), Embeds: []*discordgo.MessageEmbed{embed}}
+	f.messages[msg.ID] = msg
+	return msg, nil
+}
+
+func (f *fakeBoardSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.deleted = append(f.deleted, messageID)
+	delete(f.messages, messageID)
+	return nil
+}
+
+func (f *fakeBoardSession) ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.messages[messageID].Pinned = true
+	return nil
+}
+
+func TestUpdateBoard(t *testing.T) {
+	b := &fuelBot{log: zap.NewNop().Sugar()}
+	session := newFakeBoardSession()
+	embeds := func(n int) []*discordgo.MessageEmbed {
+		var out []*discordgo.MessageEmbed
+		for i := 0; i < n; i++ {
+			out = append(out, &discordgo.MessageEmbed{Title: "Feeding status"})
+		}
+		return out
+	}
+
+	ids, err := b.updateBoard(session, "1", nil, embeds(2))
+	if err != nil || len(ids) != 2 || !session.messages[ids[0]].Pinned {
+		t.Fatalf("expected new pinned board, got: %v, %v", ids, err)
+	}
+
+	// Edited in place, unpinned message is pinned again.
+	session.messages[ids[1]].Pinned = false
+	edited, err := b.updateBoard(session, "1", ids, embeds(2))
+	if err != nil || !reflect.DeepEqual(edited, ids) || session.edits != 2 {
+		t.Errorf("expected board edited in place, got: %v, %d edits, %v", edited, session.edits, err)
+	}
+	if !session.messages[ids[1]].Pinned {
+		t.Error("expected unpinned board to be pinned again")
+	}
+
+	// Message count changed, board is posted again.
+	reposted, err := b.updateBoard(session, "1", ids, embeds(3))
+	if err != nil || len(reposted) != 3 || !reflect.DeepEqual(session.deleted, ids) {
+		t.Errorf("expected board posted again, got: %v, deleted %v, %v", reposted, session.deleted, err)
+	}
+
+	// Somebody deleted the message, board is recreated.
+	delete(session.messages, reposted[1])
+	recreated, err := b.updateBoard(session, "1", reposted, embeds(3))
+	if err != nil || len(recreated) != 3 || recreated[0] == reposted[0] {
+		t.Errorf("expected board recreated after 404, got: %v, %v", recreated, err)
+	}
+
+	// Other errors keep the board.
+	session.editErr = errors.New("connection reset")
+	kept, err := b.updateBoard(session, "1", recreated, embeds(3))
+	if err == nil || !reflect.DeepEqual(kept, recreated) {
+		t.Errorf("expected board IDs kept after error, got: %v, %v", kept, err)
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
 	"github.com/lunemec/eve-fuelbot/pkg/token"
 
 	"github.com/antihax/goesi"
 	"github.com/antihax/goesi/esi"
 	"github.com/bwmarrin/discordgo"
-	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 )
 
@@ -21,19 +25,30 @@ type Bot interface {
 }
 
 type fuelBot struct {
-	tokenSource token.Source
-	log         logger
-	esi         *goesi.APIClient
-	discord     *discordgo.Session
-	channelID   string
+	tokenSources []token.Source
+	log          logger
+	esi          *goesi.APIClient
+	discord      *discordgo.Session
+	notifier     notify.Notifier
+	cfg          Config
 
-	httpClient *http.Client
+	prices *price.Cache
+	sde    *sde.Data
 
-	checkInterval      time.Duration
-	notifyInterval     time.Duration
-	refuelNotification time.Duration
+	stateStorage state.Storage
+	stateMu      sync.Mutex
+	state        state.State
 
-	notified map[int64]time.Time
+	cacheMu    sync.Mutex
+	systems    map[int32]solarSystem
+	locations  map[int64]location
+	assets     map[int32][]asset
+	structures []structureData
+	starbases  []starbaseData
+
+	extractions []extractionData
+	// locationErrs holds when location lookup failed, guarded by cacheMu.
+	locationErrs map[int64]time.Time
 }
 
 type logger interface {
@@ -42,41 +57,131 @@ type logger interface {
 }
 
 type structureData struct {
+	Corporation     corporation
+	SolarSystem     solarSystem
 	CorporationData esi.GetCorporationsCorporationIdStructures200Ok
 	UniverseData    esi.GetUniverseStructuresStructureIdOk
+	// FuelBay holds quantity of each item type in the fuel bay, nil when
+	// corporation assets could not be loaded.
+	FuelBay map[int32]int64
+	// Rigs are type IDs of fitted rigs, nil when corporation assets could
+	// not be loaded.
+	Rigs []int32
+}
+
+// corporation monitored by the bot, and character whose token is used
+// to read its data.
+type corporation struct {
+	ID     int32
+	Name   string
+	Ticker string
+
+	CharacterID   int32
+	CharacterName string
+	tokenSource   token.Source
+}
+
+// ctx returns context authenticated with corporation character token.
+func (c corporation) ctx() context.Context {
+	return context.WithValue(context.Background(), goesi.ContextOAuth2, c.tokenSource)
+}
+
+// Config of the bot behaviour.
+type Config struct {
+	// CheckInterval is how often to check EVE ESI API.
+	CheckInterval time.Duration
+	// RefuelDetection is how much fuel expiration has to move forward
+	// to report structure as refuelled.
+	RefuelDetection time.Duration
+	// Thresholds of fuel notifications.
+	Thresholds Thresholds
+	// Routes send alerts of matching structures to other notifiers.
+	Routes []Route
+	// Escalations re-post fuel alerts of structures not refuelled in time.
+	Escalations []Escalation
+	// Ack configures alert buttons.
+	Ack AckConfig
+	// Board configures pinned status boards.
+	Board BoardConfig
+	// Digest configures scheduled fuel digest.
+	Digest Digest
+	// Deferred holds alerts held back by quiet hours.
+	Deferred *notify.Queue
+	// Stock configures corporation hangar fuel stock warnings.
+	Stock StockConfig
+	// Ozone configures low liquid ozone warnings of jump gates.
+	Ozone     OzoneConfig
+	Strontium StrontiumConfig
+	// Extraction configures moon extraction notifications.
+	Extraction ExtractionConfig
+	// Notification configures forwarding of in-game notifications.
+	Notification NotificationConfig
 }
 
 // NewFuelBot returns new bot instance.
-func NewFuelBot(log logger, client *http.Client, tokenSource token.Source, discord *discordgo.Session, channelID string, checkInterval, notifyInterval, refuelNotification time.Duration) Bot {
+func NewFuelBot(log logger, client *http.Client, tokenSources []token.Source, stateStorage state.Storage, prices *price.Cache, sdeData *sde.Data, discord *discordgo.Session, notifier notify.Notifier, cfg Config) Bot {
 	log.Infow("EVE FuelBot starting",
-		"check_interval", checkInterval,
-		"notify_interval", notifyInterval,
-		"refuel_notification", refuelNotification,
+		"check_interval", cfg.CheckInterval,
+		"refuel_detection", cfg.RefuelDetection,
+		"thresholds", cfg.Thresholds,
+		"stock", cfg.Stock,
+		"ozone", cfg.Ozone,
+		"extraction", cfg.Extraction,
+		"notification", cfg.Notification,
+		"characters", len(tokenSources),
 	)
 	esi := goesi.NewAPIClient(client, "EVE FuelBot")
 	return &fuelBot{
-		tokenSource:        tokenSource,
-		log:                log,
-		esi:                esi,
-		discord:            discord,
-		channelID:          channelID,
-		httpClient:         &http.Client{Timeout: 5 * time.Second},
-		checkInterval:      checkInterval,
-		notifyInterval:     notifyInterval,
-		refuelNotification: refuelNotification,
-		notified:           make(map[int64]time.Time),
+		tokenSources: tokenSources,
+		log:          log,
+		esi:          esi,
+		discord:      discord,
+		notifier:     notifier,
+		cfg:          cfg,
+		prices:       prices,
+		sde:          sdeData,
+		stateStorage: stateStorage,
+		state:        state.New(),
+		systems:      make(map[int32]solarSystem),
+		locations:    make(map[int64]location),
+		locationErrs: make(map[int64]time.Time),
+		assets:       make(map[int32][]asset),
 	}
 }
 
 // Bot - you know, do what a bot does.
 func (b *fuelBot) Bot() error {
-	err := b.discord.Open()
+	st, err := b.stateStorage.Read()
 	if err != nil {
-		return errors.Wrap(err, "unable to connect to discord")
+		// Log but do not return error, worst case we notify again.
+		b.log.Errorw("Error reading state, starting with empty state",
+			"error", errors.Wrap(err, "unable to read state"),
+		)
 	}
+	b.state = st
+
 	// Add handler to listen for "!fuel" messages to report all structures fuel
 	// expiration date.
 	b.discord.AddHandler(b.messageFuelHandler)
+	// Add handler for "/fuel" slash command and its autocomplete.
+	b.discord.AddHandler(b.interactionHandler)
+
+	err = b.discord.Open()
+	if err != nil {
+		return errors.Wrap(err, "unable to connect to discord")
+	}
+	err = b.registerCommands()
+	if err != nil {
+		// Log but do not return error, "!fuel" still works.
+		b.log.Errorw("Error registering slash commands", "error", err)
+	}
+
+	// Keep fuel prices fresh in the background, so slow market API
+	// does not slow down responses.
+	go b.prices.Run(PriceTypeIDs())
+	if b.cfg.Digest.schedule != nil {
+		go b.runDigest()
+	}
 
 	for {
 		structs, err := b.loadStructures()
@@ -88,127 +193,377 @@ func (b *fuelBot) Bot() error {
 		}
 
 		// In case of previous error, we are iterating 0 times over nil slice.
-		for _, structure := range structs {
-			notify := b.shouldNotify(structure)
-			if notify {
-				b.log.Infow("Sending message",
-					"channel_id", b.channelID,
-					"structure_id", structure.CorporationData.StructureId,
-					"structure_name", structure.UniverseData.Name,
-				)
-				_, err = b.discord.ChannelMessageSendEmbed(b.channelID, b.message(&structure))
-				switch {
-				case err != nil:
-					b.log.Errorw("Error sending discord message",
-						"error", errors.Wrap(err, "error sending discord message"),
-					)
-					// In case of error, we fall through to the time.Sleep
-					// block. We also do not set the structure as notified
-					// and it get picked up on next iteration.
-					continue
-				case err == nil:
-					b.setWasNotified(structure)
-				}
-			}
+		b.checkRefuels(structs, b.loadedStarbases())
+		b.checkFuel(structs)
+		b.checkEscalations(structs)
+		b.checkGas(structs)
+		b.checkOzone(structs)
+		b.checkExtractions(b.loadedExtractions())
+		b.checkNotifications()
+		b.checkStarbaseFuel(b.loadedStarbases())
+		b.checkStrontium(b.loadedStarbases())
+		b.checkStates(structs)
+		b.checkStock(structs)
+		b.updateBoards(structs)
+		b.recordConsumption(structs)
+
+		time.Sleep(b.cfg.CheckInterval)
+	}
+}
+
+// checkFuel sends notification for structures running out of fuel.
+func (b *fuelBot) checkFuel(structs []structureData) {
+	for _, structure := range structs {
+		target := b.structureTarget(structure)
+		threshold, notify := b.shouldNotify(resourceFuel, target, structure.CorporationData.FuelExpires)
+		if !notify {
+			continue
 		}
+		b.log.Infow("Sending message",
+			"structure_id", structure.CorporationData.StructureId,
+			"structure_name", structure.UniverseData.Name,
+			"corporation", structure.Corporation.Name,
+			"threshold", threshold.Before,
+		)
+		err := b.sendAlert(target, threshold, b.message(&structure, threshold))
+		if err != nil {
+			// In case of error, we do not set the structure as notified
+			// and it get picked up on next iteration.
+			continue
+		}
+		b.setWasNotified(resourceFuel, structure.CorporationData.StructureId, threshold)
+		b.startEscalation(structure.CorporationData.StructureId)
+	}
+}
+
+// send sends message with embed about target through notifier of the
+// first route matching it, or the default notifier.
+func (b *fuelBot) send(t target, content string, embed *discordgo.MessageEmbed) error {
+	return b.sendMessage(t, notify.Message{
+		Content: content,
+		Embed:   embed,
+	})
+}
 
-		time.Sleep(b.checkInterval)
+func (b *fuelBot) sendMessage(t target, msg notify.Message) error {
+	notifier, routeName := b.notifier, "default"
+	if route, ok := b.route(t); ok {
+		notifier, routeName = route.notifier, route.name
 	}
+	msg.TargetID = t.ID
+	err := b.deliver(notifier, msg)
+	if err != nil {
+		err = errors.Wrap(err, "error sending message")
+		b.log.Errorw("Error sending message",
+			"route", routeName,
+			"error", err,
+		)
+	}
+	return err
 }
 
-func (b *fuelBot) message(structure *structureData) *discordgo.MessageEmbed {
+// deliver sends msg through notifier. Failures of only some of the
+// notifiers are logged, the message was delivered and is not sent again.
+func (b *fuelBot) deliver(notifier notify.Notifier, msg notify.Message) error {
+	err := notifier.Notify(msg)
+	var partial *notify.PartialError
+	if errors.As(err, &partial) {
+		for _, e := range partial.Errs {
+			b.log.Errorw("Error sending message to notifier", "error", e)
+		}
+		return nil
+	}
+	return err
+}
+
+func (b *fuelBot) message(structure *structureData, threshold Threshold) *discordgo.MessageEmbed {
 	whereMsg := "`%s`"
 	whereMsg = fmt.Sprintf(whereMsg, structure.UniverseData.Name)
 
-	whenMsg := fmt.Sprintf("`%s` (%s)",
-		humanize.Time(structure.CorporationData.FuelExpires),
+	whenMsg := fmt.Sprintf("%s (%s)",
+		formatTime(structure.CorporationData.FuelExpires),
 		structure.CorporationData.FuelExpires,
 	)
 
-	return &discordgo.MessageEmbed{
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: "https://i.imgur.com/pKEZq6F.png",
+	whoMsg := fmt.Sprintf("`%s` [%s]", structure.Corporation.Name, structure.Corporation.Ticker)
+
+	return newEmbed("Citadel running out of fuel, FEED IT!", threshold.Color, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: whereMsg,
 		},
-		Color: 0xff0000,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:  "Where?!",
-				Value: whereMsg,
-			},
-			{
-				Name:  "When?!",
-				Value: whenMsg,
-			},
+		{
+			Name:  "When?!",
+			Value: whenMsg,
 		},
-		Timestamp: time.Now().Format(time.RFC3339), // Discord wants ISO8601; RFC3339 is an extension of ISO8601 and should be completely compatible.
-		Title:     "Citadel running out of fuel, FEED IT!",
+		{
+			Name:  "Whose?!",
+			Value: whoMsg,
+		},
+	})
+}
+
+// corporations returns all corporations of logged in characters. When
+// more characters are in the same corporation, first one is used.
+func (b *fuelBot) corporations() ([]corporation, error) {
+	var (
+		out  []corporation
+		seen = make(map[int32]bool)
+	)
+	for _, tokenSource := range b.tokenSources {
+		corp, err := b.corporation(tokenSource)
+		if err != nil {
+			// Log but continue, other characters may still work.
+			b.log.Errorw("Error loading character corporation",
+				"character", tokenSource.Name(),
+				"error", err,
+			)
+			continue
+		}
+		if seen[corp.ID] {
+			continue
+		}
+		seen[corp.ID] = true
+		out = append(out, corp)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("unable to load corporation for any character")
 	}
+	return out, nil
 }
 
-func (b *fuelBot) loadStructures() ([]structureData, error) {
-	v, err := b.tokenSource.Verify()
+func (b *fuelBot) corporation(tokenSource token.Source) (corporation, error) {
+	v, err := tokenSource.Verify()
 	if err != nil {
-		return nil, errors.Wrap(err, "token verify error")
+		return corporation{}, errors.Wrap(err, "token verify error")
 	}
 
-	ctx := context.WithValue(context.Background(), goesi.ContextOAuth2, b.tokenSource)
+	ctx := context.WithValue(context.Background(), goesi.ContextOAuth2, tokenSource)
 	characterInfo, _, err := b.esi.ESI.CharacterApi.GetCharactersCharacterId(ctx, v.CharacterID, nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to get character info")
+		return corporation{}, errors.Wrap(err, "unable to get character info")
+	}
+	corporationInfo, _, err := b.esi.ESI.CorporationApi.GetCorporationsCorporationId(ctx, characterInfo.CorporationId, nil)
+	if err != nil {
+		return corporation{}, errors.Wrap(err, "unable to get corporation info")
+	}
+	return corporation{
+		ID:            characterInfo.CorporationId,
+		Name:          corporationInfo.Name,
+		Ticker:        corporationInfo.Ticker,
+		CharacterID:   v.CharacterID,
+		CharacterName: v.CharacterName,
+		tokenSource:   tokenSource,
+	}, nil
+}
+
+// loadStructures loads structures of all corporations, and their
+// starbases and moon extractions available from loadedStarbases and
+// loadedExtractions. Corporations which fail
+// to load are logged and skipped.
+func (b *fuelBot) loadStructures() ([]structureData, error) {
+	corps, err := b.corporations()
+	if err != nil {
+		return nil, err
 	}
 
-	corpStructures, _, err := b.esi.ESI.CorporationApi.GetCorporationsCorporationIdStructures(ctx, characterInfo.CorporationId, nil)
+	var (
+		out         []structureData
+		starbases   []starbaseData
+		extractions []extractionData
+		loaded      bool
+	)
+	for _, corp := range corps {
+		structures, err := b.loadCorporationStructures(corp)
+		if err != nil {
+			b.log.Errorw("Error loading corporation structures",
+				"corporation", corp.Name,
+				"character", corp.CharacterName,
+				"error", err,
+			)
+			continue
+		}
+		loaded = true
+		out = append(out, structures...)
+
+		// Starbases are optional, older tokens may be missing the
+		// starbases scope.
+		corpStarbases, err := b.loadCorporationStarbases(corp)
+		if err != nil {
+			b.log.Errorw("Error loading corporation starbases",
+				"corporation", corp.Name,
+				"error", err,
+			)
+		}
+		starbases = append(starbases, corpStarbases...)
+
+		// Extractions are optional too, older tokens may be missing
+		// the mining scope.
+		corpExtractions, err := b.loadCorporationExtractions(corp, structures)
+		if err != nil {
+			b.log.Errorw("Error loading corporation moon extractions",
+				"corporation", corp.Name,
+				"error", err,
+			)
+		}
+		extractions = append(extractions, corpExtractions...)
+	}
+	sortExtractions(extractions)
+	if !loaded {
+		return nil, errors.New("unable to load structures for any corporation")
+	}
+
+	b.cacheMu.Lock()
+	b.structures = out
+	b.starbases = starbases
+	b.extractions = extractions
+	b.cacheMu.Unlock()
+	return out, nil
+}
+
+// loadedStructures returns structures from the last successful
+// loadStructures call.
+func (b *fuelBot) loadedStructures() []structureData {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+	return b.structures
+}
+
+func (b *fuelBot) loadCorporationStructures(corp corporation) ([]structureData, error) {
+	ctx := corp.ctx()
+	corpStructures, _, err := b.esi.ESI.CorporationApi.GetCorporationsCorporationIdStructures(ctx, corp.ID, nil)
 	if err != nil {
-		e := err.(esi.GenericSwaggerError)
+		e, _ := err.(esi.GenericSwaggerError)
 		return nil, errors.Wrapf(err, "unable to read corporation structures: %s", e.Model())
 	}
 
+	// Fuel bay contents are optional, older tokens may be missing the
+	// assets scope or the character may not be a Director.
+	assets, err := b.loadAssets(corp)
+	if err != nil {
+		b.log.Errorw("Error loading corporation assets, fuel bay contents unknown",
+			"corporation", corp.Name,
+			"error", err,
+		)
+	}
+	bays := fuelBays(assets)
+	rigs := fittedRigs(assets)
+	if assets != nil {
+		b.cacheMu.Lock()
+		b.assets[corp.ID] = assets
+		b.cacheMu.Unlock()
+	}
+
 	var out []structureData
 	for _, structure := range corpStructures {
 		structureInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseStructuresStructureId(ctx, structure.StructureId, nil)
 		if err != nil {
 			return nil, errors.Wrapf(err, "unable to load strucutre info for structure: %d", structure.StructureId)
 		}
+		system, err := b.solarSystem(ctx, structureInfo.SolarSystemId)
+		if err != nil {
+			return nil, err
+		}
 		out = append(out, structureData{
+			Corporation:     corp,
+			SolarSystem:     system,
 			CorporationData: structure,
 			UniverseData:    structureInfo,
+			FuelBay:         bays[structure.StructureId],
+			Rigs:            rigs[structure.StructureId],
 		})
 	}
 	return out, nil
 }
 
-// shouldNotify checks if given strucutre should be notified
-// right now.
-func (b *fuelBot) shouldNotify(structure structureData) bool {
-	expires := structure.CorporationData.FuelExpires
+// resource a structure can run out of, notifications of each resource
+// are repeated independently.
+type resource int
+
+const (
+	resourceFuel resource = iota
+	resourceGas
+)
+
+// notified returns notifications sent for resource, b.stateMu must be held.
+func (b *fuelBot) notified(r resource) map[int64]state.Notification {
+	switch r {
+	case resourceGas:
+		return b.state.GasNotified
+	}
+	return b.state.Notified
+}
+
+// shouldNotify checks if structure or starbase t should be notified
+// about running out of resource right now and returns the most urgent
+// threshold of its route it is within.
+func (b *fuelBot) shouldNotify(r resource, t target, expires time.Time) (Threshold, bool) {
 	// Structures already expired (unfueled).
 	if expires.IsZero() {
-		return false
+		return Threshold{}, false
+	}
+	threshold, ok := b.thresholds(t).find(time.Until(expires))
+	if !ok {
+		return Threshold{}, false
 	}
-	if time.Until(expires) <= time.Duration(b.refuelNotification) {
-		// If we already were notified, don't send message for notifyInterval duration.
-		return !b.wasNotified(structure)
+	// Claimed, snoozed or ignored alerts are not repeated.
+	if _, ok := b.acked(t.ID); ok {
+		return Threshold{}, false
 	}
-	return false
+	// If we already were notified, don't send message for threshold interval.
+	return threshold, !b.wasNotified(r, t.ID, threshold)
 }
 
-// setWasNotified stores information that structure was already
-// notified at time.Now()
-func (b *fuelBot) setWasNotified(structure structureData) {
-	id := structure.CorporationData.StructureId
-	b.notified[id] = time.Now()
+// fuelCritical checks if target running out of fuel at expires is within
+// critical threshold.
+func (b *fuelBot) fuelCritical(t target, expires time.Time) bool {
+	if expires.IsZero() {
+		return false
+	}
+	threshold, ok := b.thresholds(t).find(time.Until(expires))
+	return ok && threshold.Critical
+}
+
+// setWasNotified stores information that structure or starbase was
+// already notified about resource at time.Now() for given threshold and
+// persists it, so restarts do not send the notification again.
+func (b *fuelBot) setWasNotified(r resource, id int64, threshold Threshold) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	b.notified(r)[id] = state.Notification{
+		At:        time.Now(),
+		Threshold: threshold.Before,
+	}
+	b.saveState()
 }
 
-// wasNotified checks if this structure was notified within
-// b.notifyInterval.
-func (b *fuelBot) wasNotified(structure structureData) bool {
-	id := structure.CorporationData.StructureId
-	notifyTime, ok := b.notified[id]
+// wasNotified checks if this structure was notified about resource
+// within threshold interval. Notification for less urgent threshold
+// does not count, so crossing into more urgent threshold notifies
+// right away.
+func (b *fuelBot) wasNotified(r resource, id int64, threshold Threshold) bool {
+	b.stateMu.Lock()
+	notification, ok := b.notified(r)[id]
+	b.stateMu.Unlock()
 	if !ok {
 		return false
 	}
-	if time.Since(notifyTime) > b.notifyInterval {
+	if notification.Threshold > threshold.Before {
+		return false
+	}
+	if time.Since(notification.At) > threshold.Interval {
 		return false
 	}
 	return true
 }
+
+// saveState writes current state to the storage, b.stateMu must be held.
+func (b *fuelBot) saveState() {
+	err := b.stateStorage.Write(b.state)
+	if err != nil {
+		// Log but do not return error, state is kept in memory.
+		b.log.Errorw("Error saving state",
+			"error", errors.Wrap(err, "unable to save state"),
+		)
+	}
+}
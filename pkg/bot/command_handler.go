@@ -0,0 +1,347 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const (
+	fuelCommandName            = "fuel"
+	fuelCommandStructureOption = "structure"
+	fuelCommandSystemOption    = "system"
+	stockCommandName           = "fuel-stock"
+	planCommandName            = "fuel-plan"
+	planCommandDaysOption      = "days"
+	moonsCommandName           = "fuel-moons"
+
+	// Discord allows at most 25 autocomplete choices.
+	maxAutocompleteChoices = 25
+)
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        fuelCommandName,
+		Description: "Report structures fuel expiration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         fuelCommandStructureOption,
+				Description:  "Only report this structure",
+				Autocomplete: true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         fuelCommandSystemOption,
+				Description:  "Only report structures in this solar system",
+				Autocomplete: true,
+			},
+		},
+	},
+	{
+		Name:        stockCommandName,
+		Description: "Report fuel stock in corporation hangars",
+	},
+	{
+		Name:        planCommandName,
+		Description: "Plan fuel needed to top up structures",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        planCommandDaysOption,
+				Description: fmt.Sprintf("Days of fuel to top up to (default %d)", defaultPlanDays),
+				MinValue:    &planMinDays,
+			},
+		},
+	},
+	{
+		Name:        moonsCommandName,
+		Description: "Report moon extractions",
+	},
+}
+
+var planMinDays float64 = 1
+
+// registerCommands registers slash commands globally, replacing
+// any previously registered commands.
+func (b *fuelBot) registerCommands() error {
+	_, err := b.discord.ApplicationCommandBulkOverwrite(b.discord.State.User.ID, "", commands)
+	return errors.Wrap(err, "unable to register slash commands")
+}
+
+// interactionHandler will be called every time a slash command is used
+// or autocompleted, or alert button is pressed.
+func (b *fuelBot) interactionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		switch i.ApplicationCommandData().Name {
+		case fuelCommandName:
+			b.fuelCommandHandler(s, i)
+		case stockCommandName:
+			b.stockCommandHandler(s, i)
+		case planCommandName:
+			b.planCommandHandler(s, i)
+		case moonsCommandName:
+			b.moonsCommandHandler(s, i)
+		}
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		if i.ApplicationCommandData().Name == fuelCommandName {
+			b.fuelAutocompleteHandler(s, i)
+		}
+	case discordgo.InteractionMessageComponent:
+		if strings.HasPrefix(i.MessageComponentData().CustomID, ackPrefix+":") {
+			b.ackHandler(s, i)
+		}
+	}
+}
+
+func (b *fuelBot) fuelCommandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Loading structures takes longer than the 3s Discord waits for response.
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		b.log.Errorw("error responding to /fuel command", "err", err)
+		return
+	}
+
+	structs, err := b.loadStructures()
+	if err != nil {
+		b.log.Errorw("error loading structure information", "err", err)
+		b.interactionError(s, i, "Error loading structure information.")
+		return
+	}
+
+	options := commandOptions(i.ApplicationCommandData().Options)
+	structs = filterStructures(structs, options[fuelCommandStructureOption], options[fuelCommandSystemOption])
+	starbases := filterStarbases(b.loadedStarbases(), options[fuelCommandStructureOption], options[fuelCommandSystemOption])
+	if len(structs) == 0 && len(starbases) == 0 {
+		b.interactionError(s, i, "No structures found.")
+		return
+	}
+
+	b.log.Infow("Sending response to /fuel command",
+		"channel_id", i.ChannelID,
+		"options", options,
+	)
+	b.interactionEmbeds(s, i, b.allStructuresMessage(structs, starbases))
+}
+
+func (b *fuelBot) stockCommandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		b.log.Errorw("error responding to /fuel-stock command", "err", err)
+		return
+	}
+
+	embeds, err := b.stockReport()
+	if err != nil {
+		b.log.Errorw("error loading fuel stock", "err", err)
+		b.interactionError(s, i, "Error loading fuel stock.")
+		return
+	}
+
+	b.log.Infow("Sending response to /fuel-stock command",
+		"channel_id", i.ChannelID,
+	)
+	b.interactionEmbeds(s, i, embeds)
+}
+
+func (b *fuelBot) planCommandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		b.log.Errorw("error responding to /fuel-plan command", "err", err)
+		return
+	}
+
+	days := defaultPlanDays
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == planCommandDaysOption {
+			days = int(option.IntValue())
+		}
+	}
+	plan, err := b.Plan(days)
+	if err != nil {
+		b.log.Errorw("error planning fuel", "err", err)
+		b.interactionError(s, i, "Error loading structure information.")
+		return
+	}
+
+	b.log.Infow("Sending response to /fuel-plan command",
+		"channel_id", i.ChannelID,
+		"days", days,
+	)
+	b.interactionEmbeds(s, i, b.planMessage(plan))
+}
+
+func (b *fuelBot) moonsCommandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		b.log.Errorw("error responding to /fuel-moons command", "err", err)
+		return
+	}
+
+	_, err = b.loadStructures()
+	if err != nil {
+		b.log.Errorw("error loading structure information", "err", err)
+		b.interactionError(s, i, "Error loading structure information.")
+		return
+	}
+
+	b.log.Infow("Sending response to /fuel-moons command",
+		"channel_id", i.ChannelID,
+	)
+	b.interactionEmbeds(s, i, b.extractionsMessage(b.loadedExtractions()))
+}
+
+// interactionEmbeds sends first embed as the interaction response and
+// the rest as followup messages, each embed in its own message to stay
+// within Discord message limits.
+func (b *fuelBot) interactionEmbeds(s *discordgo.Session, i *discordgo.InteractionCreate, embeds []*discordgo.MessageEmbed) {
+	if len(embeds) == 0 {
+		b.interactionError(s, i, "Nothing to show.")
+		return
+	}
+	first := embeds[:1]
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &first,
+	})
+	if err != nil {
+		b.log.Errorw("error sending interaction response", "err", err)
+		return
+	}
+	for _, embed := range embeds[1:] {
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+		if err != nil {
+			b.log.Errorw("error sending interaction followup", "err", err)
+			return
+		}
+	}
+}
+
+func (b *fuelBot) interactionError(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &msg,
+	})
+	if err != nil {
+		b.log.Errorw("error sending interaction response", "err", err)
+	}
+}
+
+// fuelAutocompleteHandler suggests structure or system names from the
+// last loaded structures, so typing does not call ESI.
+func (b *fuelBot) fuelAutocompleteHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var (
+		focused *discordgo.ApplicationCommandInteractionDataOption
+		names   = make(map[string]bool)
+	)
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Focused {
+			focused = option
+		}
+	}
+	if focused == nil {
+		return
+	}
+
+	typed := strings.ToLower(focused.StringValue())
+	for _, structure := range b.loadedStructures() {
+		var name string
+		switch focused.Name {
+		case fuelCommandStructureOption:
+			name = structure.UniverseData.Name
+		case fuelCommandSystemOption:
+			name = structure.SolarSystem.Name
+		}
+		if name != "" && strings.Contains(strings.ToLower(name), typed) {
+			names[name] = true
+		}
+	}
+	for _, starbase := range b.loadedStarbases() {
+		var name string
+		switch focused.Name {
+		case fuelCommandStructureOption:
+			name = starbase.Name()
+		case fuelCommandSystemOption:
+			name = starbase.SolarSystem.Name
+		}
+		if name != "" && strings.Contains(strings.ToLower(name), typed) {
+			names[name] = true
+		}
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for name := range names {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: name,
+		})
+	}
+	sort.Slice(choices, func(i, j int) bool {
+		return choices[i].Name < choices[j].Name
+	})
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		b.log.Errorw("error responding to /fuel autocomplete", "err", err)
+	}
+}
+
+func commandOptions(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]string {
+	out := make(map[string]string)
+	for _, option := range options {
+		out[option.Name] = fmt.Sprint(option.Value)
+	}
+	return out
+}
+
+// filterStructures returns structures matching structure and system name,
+// empty name matches everything.
+func filterStructures(structures []structureData, structureName, systemName string) []structureData {
+	var out []structureData
+	for _, structure := range structures {
+		if structureName != "" && !strings.EqualFold(structure.UniverseData.Name, structureName) {
+			continue
+		}
+		if systemName != "" && !strings.EqualFold(structure.SolarSystem.Name, systemName) {
+			continue
+		}
+		out = append(out, structure)
+	}
+	return out
+}
+
+// filterStarbases returns starbases matching moon and system name,
+// empty name matches everything.
+func filterStarbases(starbases []starbaseData, moonName, systemName string) []starbaseData {
+	var out []starbaseData
+	for _, starbase := range starbases {
+		if moonName != "" && !strings.EqualFold(starbase.Name(), moonName) {
+			continue
+		}
+		if systemName != "" && !strings.EqualFold(starbase.SolarSystem.Name, systemName) {
+			continue
+		}
+		out = append(out, starbase)
+	}
+	return out
+}
@@ -0,0 +1,252 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/schedule"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const (
+	consumptionDateFormat = "2006-01-02"
+	// consumptionRetention is how many days of consumption are kept for
+	// week-over-week comparison.
+	consumptionRetention = 14
+)
+
+// DigestConfig configures scheduled fuel digest, cron-style Schedule
+// like "0 9 * * mon". Digest is sent to Notifiers, or to the default
+// notifier when there are none.
+type DigestConfig struct {
+	Schedule  string   `mapstructure:"schedule"`
+	Notifiers []string `mapstructure:"notifiers"`
+}
+
+// Digest is validated DigestConfig, zero Digest is disabled.
+type Digest struct {
+	schedule *schedule.Schedule
+	notifier notify.Notifier
+}
+
+// NewDigest parses digest schedule and resolves its notifiers by name.
+func NewDigest(cfg DigestConfig, notifiers map[string]notify.Notifier) (Digest, error) {
+	if cfg.Schedule == "" {
+		return Digest{}, nil
+	}
+	s, err := schedule.Parse(cfg.Schedule)
+	if err != nil {
+		return Digest{}, errors.Wrap(err, "digest")
+	}
+	digest := Digest{schedule: &s}
+	if len(cfg.Notifiers) != 0 {
+		digest.notifier, err = namedNotifier(cfg.Notifiers, notifiers)
+		if err != nil {
+			return Digest{}, errors.Wrap(err, "digest")
+		}
+	}
+	return digest, nil
+}
+
+// bandChange is structure or starbase which changed fuel colour band
+// since the last digest.
+type bandChange struct {
+	Name     string
+	Previous string
+	Current  string
+}
+
+// runDigest sends digest on schedule, forever.
+func (b *fuelBot) runDigest() {
+	for {
+		next := b.cfg.Digest.schedule.Next(time.Now())
+		if next.IsZero() {
+			b.log.Errorw("Digest schedule never matches, digest is disabled")
+			return
+		}
+		time.Sleep(time.Until(next))
+		b.sendDigest()
+	}
+}
+
+// sendDigest sends summary of the last loaded structures and starbases
+// sorted by fuel expiration, with colour band changes since the last
+// digest and week-over-week change of fuel consumption.
+func (b *fuelBot) sendDigest() {
+	structs := append([]structureData(nil), b.loadedStructures()...)
+	starbases := append([]starbaseData(nil), b.loadedStarbases()...)
+	if len(structs) == 0 && len(starbases) == 0 {
+		b.log.Errorw("Skipping digest, no structures loaded")
+		return
+	}
+	// Unfuelled structures have zero expiration and go first.
+	sort.SliceStable(structs, func(i, j int) bool {
+		return structs[i].CorporationData.FuelExpires.Before(structs[j].CorporationData.FuelExpires)
+	})
+	sort.SliceStable(starbases, func(i, j int) bool {
+		return starbases[i].FuelExpires().Before(starbases[j].FuelExpires())
+	})
+
+	var (
+		bands = make(map[int64]string)
+		names = make(map[int64]string)
+	)
+	for _, structure := range structs {
+		id := structure.CorporationData.StructureId
+		bands[id] = fuelSymbol(structure.CorporationData.FuelExpires)
+		names[id] = structure.UniverseData.Name
+	}
+	for _, starbase := range starbases {
+		id := starbase.CorporationData.StarbaseId
+		bands[id] = fuelSymbol(starbase.FuelExpires())
+		names[id] = starbase.Name()
+	}
+
+	b.stateMu.Lock()
+	changes := bandChanges(b.state.DigestBands, bands, names)
+	current, previous, compared := weekOverWeek(b.state.Consumption, time.Now())
+	b.stateMu.Unlock()
+
+	notifier := b.cfg.Digest.notifier
+	if notifier == nil {
+		notifier = b.notifier
+	}
+	embeds := append(
+		[]*discordgo.MessageEmbed{digestMessage(changes, current, previous, compared)},
+		b.allStructuresMessage(structs, starbases)...,
+	)
+	b.log.Infow("Sending digest",
+		"structures", len(structs),
+		"starbases", len(starbases),
+		"band_changes", len(changes),
+	)
+	for _, embed := range embeds {
+		err := b.deliver(notifier, notify.Message{Embed: embed})
+		if err != nil {
+			// Bands are not updated, so changes are in the next digest.
+			b.log.Errorw("Error sending digest", "error", errors.Wrap(err, "error sending message"))
+			return
+		}
+	}
+
+	b.stateMu.Lock()
+	b.state.DigestBands = bands
+	b.saveState()
+	b.stateMu.Unlock()
+}
+
+// recordConsumption remembers today's fuel consumption of all
+// structures and starbases for week-over-week comparison.
+func (b *fuelBot) recordConsumption(structs []structureData) {
+	starbases := b.loadedStarbases()
+	if len(structs) == 0 && len(starbases) == 0 {
+		// Structures failed to load, zero would skew the average.
+		return
+	}
+	var perDay float64
+	for _, structure := range structs {
+		perDay += b.structureFuelPerDay(structure, b.structureByTypeID(structure.CorporationData.TypeId))
+	}
+	for _, starbase := range starbases {
+		perDay += starbase.FuelPerDay()
+	}
+
+	now := time.Now()
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	b.state.Consumption[now.Format(consumptionDateFormat)] = perDay
+	oldest := now.AddDate(0, 0, -consumptionRetention).Format(consumptionDateFormat)
+	for date := range b.state.Consumption {
+		// Dates in this format sort the same as strings.
+		if date < oldest {
+			delete(b.state.Consumption, date)
+		}
+	}
+	b.saveState()
+}
+
+// bandChanges returns structures and starbases whose colour band changed
+// since previous digest, structures not in previous digest are skipped.
+func bandChanges(previous, current map[int64]string, names map[int64]string) []bandChange {
+	var out []bandChange
+	for id, band := range current {
+		previousBand, ok := previous[id]
+		if !ok || previousBand == band {
+			continue
+		}
+		out = append(out, bandChange{
+			Name:     names[id],
+			Previous: previousBand,
+			Current:  band,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// weekOverWeek returns average daily fuel consumption in the last 7
+// days and in the 7 days before, compared is false when either week has
+// no data.
+func weekOverWeek(consumption map[string]float64, now time.Time) (current, previous float64, compared bool) {
+	average := func(from int) (float64, bool) {
+		var (
+			sum  float64
+			days int
+		)
+		for day := from; day < from+7; day++ {
+			perDay, ok := consumption[now.AddDate(0, 0, -day).Format(consumptionDateFormat)]
+			if !ok {
+				continue
+			}
+			sum += perDay
+			days++
+		}
+		if days == 0 {
+			return 0, false
+		}
+		return sum / float64(days), true
+	}
+	current, currentOK := average(0)
+	previous, previousOK := average(7)
+	return current, previous, currentOK && previousOK
+}
+
+func digestMessage(changes []bandChange, current, previous float64, compared bool) *discordgo.MessageEmbed {
+	bandsMsg := "No structure changed colour since the last digest."
+	if len(changes) != 0 {
+		var lines []string
+		for _, change := range changes {
+			lines = append(lines, fmt.Sprintf("%s → %s `%s`", change.Previous, change.Current, change.Name))
+		}
+		bandsMsg = strings.Join(lines, "\n")
+	}
+
+	consumptionMsg := "Not enough history to compare weeks yet."
+	if compared {
+		consumptionMsg = fmt.Sprintf("**This week**: %.0f blocks per day\n**Previous week**: %.0f blocks per day",
+			current,
+			previous,
+		)
+		if previous > 0 {
+			consumptionMsg += fmt.Sprintf(" (%+.1f%%)", (current-previous)/previous*100)
+		}
+	}
+
+	return newEmbed("Fuel digest", 0x00ff00, []*discordgo.MessageEmbedField{
+		{
+			Name:  "New colour bands",
+			Value: bandsMsg,
+		},
+		{
+			Name:  "Consumption",
+			Value: consumptionMsg,
+		},
+	})
+}
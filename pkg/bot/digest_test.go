@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBandChanges(t *testing.T) {
+	previous := map[int64]string{1: ":green_square:", 2: ":orange_square:"}
+	current := map[int64]string{1: ":orange_square:", 2: ":orange_square:", 3: ":red_square:"}
+	names := map[int64]string{1: "Alpha", 2: "Beta", 3: "Gamma"}
+
+	changes := bandChanges(previous, current, names)
+	if len(changes) != 1 || changes[0] != (bandChange{Name: "Alpha", Previous: ":green_square:", Current: ":orange_square:"}) {
+		t.Errorf("bandChanges() = %+v, expected only Alpha", changes)
+	}
+}
+
+func TestWeekOverWeek(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	day := func(daysAgo int) string {
+		return now.AddDate(0, 0, -daysAgo).Format(consumptionDateFormat)
+	}
+
+	_, _, compared := weekOverWeek(map[string]float64{day(0): 100}, now)
+	if compared {
+		t.Error("expected no comparison without previous week")
+	}
+
+	current, previous, compared := weekOverWeek(map[string]float64{
+		day(0):  120,
+		day(1):  100,
+		day(7):  90,
+		day(13): 70,
+		day(14): 1000,
+	}, now)
+	if !compared || current != 110 || previous != 80 {
+		t.Errorf("weekOverWeek() = %.0f, %.0f, %t; expected 110, 80, true", current, previous, compared)
+	}
+}
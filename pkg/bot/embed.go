@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord rejects embeds over these limits.
+const (
+	embedMaxFields     = 25
+	embedMaxChars      = 6000
+	embedMaxFieldName  = 256
+	embedMaxFieldValue = 1024
+	// embedPageReserve is reserved for the " (12/34)" page suffix in title.
+	embedPageReserve = 16
+)
+
+// formatTime returns Discord timestamp of t shown relative to when the
+// message is read, so alerts held back by quiet hours are not stale.
+func formatTime(t time.Time) string {
+	return fmt.Sprintf("<t:%d:R>", t.Unix())
+}
+
+// newEmbed returns embed with the bot thumbnail, timestamped now.
+func newEmbed(title string, color int, fields []*discordgo.MessageEmbedField) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: "https://i.imgur.com/pKEZq6F.png",
+		},
+		Color:     color,
+		Fields:    fields,
+		Timestamp: time.Now().Format(time.RFC3339), // Discord wants ISO8601; RFC3339 is an extension of ISO8601 and should be completely compatible.
+		Title:     title,
+	}
+}
+
+// splitEmbed splits embed into as many embeds as needed to fit every
+// embed within Discord limits. Each embed is a copy of the original,
+// with subset of fields and page number in the title. Field names and
+// values over Discord limits are truncated.
+func splitEmbed(embed *discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+	embed = truncateFields(embed)
+	var (
+		pages     [][]*discordgo.MessageEmbedField
+		page      []*discordgo.MessageEmbedField
+		baseChars = embedChars(embed) + embedPageReserve
+		chars     = baseChars
+	)
+	for _, field := range embed.Fields {
+		fieldChars := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+		if len(page) > 0 && (len(page) == embedMaxFields || chars+fieldChars > embedMaxChars) {
+			pages = append(pages, page)
+			page = nil
+			chars = baseChars
+		}
+		page = append(page, field)
+		chars += fieldChars
+	}
+	pages = append(pages, page)
+
+	if len(pages) == 1 {
+		return []*discordgo.MessageEmbed{embed}
+	}
+	var out []*discordgo.MessageEmbed
+	for i, fields := range pages {
+		pageEmbed := *embed
+		pageEmbed.Title = fmt.Sprintf("%s (%d/%d)", embed.Title, i+1, len(pages))
+		pageEmbed.Fields = fields
+		out = append(out, &pageEmbed)
+	}
+	return out
+}
+
+// truncateFields returns copy of embed with field names and values cut
+// to Discord limits, embed itself is returned when all of them fit.
+func truncateFields(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	fits := true
+	for _, field := range embed.Fields {
+		if utf8.RuneCountInString(field.Name) > embedMaxFieldName || utf8.RuneCountInString(field.Value) > embedMaxFieldValue {
+			fits = false
+			break
+		}
+	}
+	if fits {
+		return embed
+	}
+	out := *embed
+	out.Fields = make([]*discordgo.MessageEmbedField, len(embed.Fields))
+	for i, field := range embed.Fields {
+		truncated := *field
+		truncated.Name = truncate(field.Name, embedMaxFieldName)
+		truncated.Value = truncate(field.Value, embedMaxFieldValue)
+		out.Fields[i] = &truncated
+	}
+	return &out
+}
+
+// truncate cuts s to at most max characters, ending with "..." when cut.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-3]) + "..."
+}
+
+// embedChars counts characters of embed without fields.
+func embedChars(embed *discordgo.MessageEmbed) int {
+	chars := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	if embed.Footer != nil {
+		chars += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		chars += utf8.RuneCountInString(embed.Author.Name)
+	}
+	return chars
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSplitEmbed(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields int
+		value  string
+		pages  int
+	}{
+		{name: "fits", fields: 10, value: "short", pages: 1},
+		{name: "too many fields", fields: 60, value: "short", pages: 3},
+		{name: "too many characters", fields: 20, value: strings.Repeat("x", 1000), pages: 4},
+	}
+	for _, test := range tests {
+		embed := &discordgo.MessageEmbed{Title: "Feeding status"}
+		for i := 0; i < test.fields; i++ {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "field", Value: test.value})
+		}
+
+		embeds := splitEmbed(embed)
+		if len(embeds) != test.pages {
+			t.Errorf("%s: expected %d embeds, got: %d", test.name, test.pages, len(embeds))
+		}
+		var fields int
+		for _, embed := range embeds {
+			fields += len(embed.Fields)
+			chars := embedChars(embed)
+			for _, field := range embed.Fields {
+				chars += len(field.Name) + len(field.Value)
+			}
+			if len(embed.Fields) > embedMaxFields || chars > embedMaxChars {
+				t.Errorf("%s: embed over limits: %d fields, %d characters", test.name, len(embed.Fields), chars)
+			}
+		}
+		if fields != test.fields {
+			t.Errorf("%s: expected %d fields in total, got: %d", test.name, test.fields, fields)
+		}
+		// Last field (the "Total fuel" block) must always be present.
+		last := embeds[len(embeds)-1]
+		if last.Fields[len(last.Fields)-1] != embed.Fields[len(embed.Fields)-1] {
+			t.Errorf("%s: last field missing from last embed", test.name)
+		}
+	}
+}
+
+func TestSplitEmbedTruncatesFields(t *testing.T) {
+	embed := &discordgo.MessageEmbed{
+		Title: "Feeding status",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "short", Value: "short"},
+			{Name: strings.Repeat("n", 300), Value: strings.Repeat("ž", 2000)},
+		},
+	}
+	embeds := splitEmbed(embed)
+	fields := embeds[0].Fields
+	if fields[0].Value != "short" {
+		t.Errorf("short field should not change: %+v", fields[0])
+	}
+	if utf8.RuneCountInString(fields[1].Name) != embedMaxFieldName || utf8.RuneCountInString(fields[1].Value) != embedMaxFieldValue {
+		t.Errorf("expected field truncated to limits, got %d name and %d value characters",
+			utf8.RuneCountInString(fields[1].Name), utf8.RuneCountInString(fields[1].Value))
+	}
+	if !strings.HasSuffix(fields[1].Value, "...") || len(embed.Fields[1].Value) != 4000 {
+		t.Error("expected truncated copy ending with ..., original kept")
+	}
+}
+
+func TestNewEmbed(t *testing.T) {
+	fields := []*discordgo.MessageEmbedField{{Name: "Where?!", Value: "`Jita`"}}
+	embed := newEmbed("Feeding status", 0x00ff00, fields)
+	if embed.Title != "Feeding status" || embed.Color != 0x00ff00 || len(embed.Fields) != 1 {
+		t.Errorf("unexpected embed: %+v", embed)
+	}
+	if embed.Thumbnail == nil || embed.Thumbnail.URL == "" {
+		t.Error("embed is missing thumbnail")
+	}
+	if _, err := time.Parse(time.RFC3339, embed.Timestamp); err != nil {
+		t.Errorf("embed timestamp is not ISO8601: %s", err)
+	}
+}
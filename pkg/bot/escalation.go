@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// EscalationConfig is one step of escalation chain. When structure is
+// not refuelled After its first fuel alert, the alert is re-posted to
+// Notifiers with Mention.
+type EscalationConfig struct {
+	After     time.Duration `mapstructure:"after"`
+	Notifiers []string      `mapstructure:"notifiers"`
+	Mention   string        `mapstructure:"mention"`
+}
+
+// Escalation is validated EscalationConfig.
+type Escalation struct {
+	after    time.Duration
+	notifier notify.Notifier
+	mention  string
+}
+
+// NewEscalations validates escalation steps, resolves their notifiers
+// by name and sorts them by After.
+func NewEscalations(configs []EscalationConfig, notifiers map[string]notify.Notifier) ([]Escalation, error) {
+	var out []Escalation
+	for i, cfg := range configs {
+		if cfg.After <= 0 {
+			return nil, errors.Errorf("escalation %d: after must be positive, got: %s", i, cfg.After)
+		}
+		notifier, err := namedNotifier(cfg.Notifiers, notifiers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "escalation %d", i)
+		}
+		out = append(out, Escalation{
+			after:    cfg.After,
+			notifier: notifier,
+			mention:  cfg.Mention,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].after < out[j].after
+	})
+	return out, nil
+}
+
+// startEscalation remembers first fuel alert of structure with id,
+// escalation runs from it until the structure is refuelled.
+func (b *fuelBot) startEscalation(id int64) {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if _, ok := b.state.Escalations[id]; ok {
+		return
+	}
+	b.state.Escalations[id] = state.Escalation{Since: time.Now()}
+	b.saveState()
+}
+
+// checkEscalations re-posts fuel alerts of structures which were not
+// refuelled for the next escalation step they are due, at most one step
+// per check, so a restart after long downtime does not post all of them
+// at once. Refuel detection removes the escalation.
+func (b *fuelBot) checkEscalations(structs []structureData) {
+	if len(b.cfg.Escalations) == 0 {
+		return
+	}
+	b.pruneEscalations(structs)
+	for _, structure := range structs {
+		id := structure.CorporationData.StructureId
+		b.stateMu.Lock()
+		escalation, ok := b.state.Escalations[id]
+		b.stateMu.Unlock()
+		if !ok || escalation.Level >= len(b.cfg.Escalations) {
+			continue
+		}
+		// Somebody is on it, or does not care.
+		if _, acked := b.acked(id); acked {
+			continue
+		}
+		step := b.cfg.Escalations[escalation.Level]
+		if time.Since(escalation.Since) < step.after {
+			continue
+		}
+
+		b.log.Infow("Sending escalation message",
+			"structure_id", id,
+			"structure_name", structure.UniverseData.Name,
+			"corporation", structure.Corporation.Name,
+			"level", escalation.Level+1,
+		)
+		threshold := b.escalationThreshold(structure)
+		err := b.deliver(step.notifier, notify.Message{
+			Content:    step.mention,
+			Embed:      b.escalationMessage(structure, escalation.Since, threshold),
+			Components: ackComponents(id),
+			Critical:   threshold.Critical,
+			TargetID:   id,
+		})
+		if err != nil {
+			// Level is not increased, so it is picked up on next iteration.
+			b.log.Errorw("Error sending escalation message",
+				"structure_id", id,
+				"error", errors.Wrap(err, "error sending message"),
+			)
+			continue
+		}
+
+		b.stateMu.Lock()
+		// Structure may have been refuelled in the meantime.
+		if _, ok := b.state.Escalations[id]; ok {
+			escalation.Level++
+			b.state.Escalations[id] = escalation
+			b.saveState()
+		}
+		b.stateMu.Unlock()
+	}
+}
+
+// pruneEscalations removes escalations of structures which are no longer
+// loaded, eg. destroyed or unanchored.
+func (b *fuelBot) pruneEscalations(structs []structureData) {
+	// Nothing loaded is most likely ESI error, keep everything.
+	if len(structs) == 0 {
+		return
+	}
+	loaded := make(map[int64]bool, len(structs))
+	for _, structure := range structs {
+		loaded[structure.CorporationData.StructureId] = true
+	}
+
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	var pruned bool
+	for id := range b.state.Escalations {
+		if !loaded[id] {
+			delete(b.state.Escalations, id)
+			pruned = true
+		}
+	}
+	if pruned {
+		b.saveState()
+	}
+}
+
+// escalationThreshold returns threshold structure is within, structure
+// out of fuel is critical.
+func (b *fuelBot) escalationThreshold(structure structureData) Threshold {
+	expires := structure.CorporationData.FuelExpires
+	threshold, ok := b.thresholds(b.structureTarget(structure)).find(time.Until(expires))
+	if !ok || expires.IsZero() {
+		return Threshold{Color: 0xff0000, Critical: expires.IsZero()}
+	}
+	return threshold
+}
+
+func (b *fuelBot) escalationMessage(structure structureData, since time.Time, threshold Threshold) *discordgo.MessageEmbed {
+	embed := b.message(&structure, threshold)
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Since?!",
+		Value: fmt.Sprintf("first alert %s (%s), still not refuelled", formatTime(since), since),
+	})
+	embed.Title = "Citadel still running out of fuel, NOBODY FED IT!"
+	return embed
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"go.uber.org/zap"
+)
+
+func TestNewEscalations(t *testing.T) {
+	notifiers := map[string]notify.Notifier{"directors": nopNotifier{}}
+	escalations, err := NewEscalations([]EscalationConfig{
+		{After: 24 * time.Hour, Notifiers: []string{"directors"}, Mention: "<@&2>"},
+		{After: 6 * time.Hour, Notifiers: []string{"directors"}, Mention: "<@&1>"},
+	}, notifiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if escalations[0].after != 6*time.Hour || escalations[1].after != 24*time.Hour {
+		t.Errorf("escalations not sorted: %s, %s", escalations[0].after, escalations[1].after)
+	}
+
+	_, err = NewEscalations([]EscalationConfig{{Notifiers: []string{"directors"}}}, notifiers)
+	if err == nil {
+		t.Error("expected error for zero after")
+	}
+}
+
+func TestCheckEscalations(t *testing.T) {
+	notifier := &recordNotifier{}
+	b := &fuelBot{
+		log: zap.NewNop().Sugar(),
+		sde: &sde.Data{},
+		cfg: Config{
+			Escalations: []Escalation{
+				{after: time.Hour, notifier: notifier},
+				{after: 2 * time.Hour, notifier: notifier},
+			},
+		},
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	// Bot was down while both steps became due.
+	b.state.Escalations[1] = state.Escalation{Since: time.Now().Add(-3 * time.Hour)}
+	b.state.Escalations[2] = state.Escalation{Since: time.Now()}
+	structs := []structureData{{
+		CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 1},
+	}}
+
+	b.checkEscalations(structs)
+	if len(notifier.msgs) != 1 || b.state.Escalations[1].Level != 1 {
+		t.Errorf("expected single escalation step, got %d messages, level %d", len(notifier.msgs), b.state.Escalations[1].Level)
+	}
+	if _, ok := b.state.Escalations[2]; ok {
+		t.Error("escalation of structure which is not loaded should be pruned")
+	}
+
+	b.checkEscalations(structs)
+	b.checkEscalations(structs)
+	if len(notifier.msgs) != 2 || b.state.Escalations[1].Level != 2 {
+		t.Errorf("expected both escalation steps, got %d messages, level %d", len(notifier.msgs), b.state.Escalations[1].Level)
+	}
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/esipage"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// ExtractionConfig configures moon extraction notifications.
+type ExtractionConfig struct {
+	// NotifyBefore is how long before chunk arrival to notify, 0
+	// disables notifications.
+	NotifyBefore time.Duration
+}
+
+// extractionData is moon extraction and where it happens.
+type extractionData struct {
+	Corporation     corporation
+	Structure       string
+	Moon            string
+	CorporationData esi.GetCorporationCorporationIdMiningExtractions200Ok
+}
+
+// loadCorporationExtractions loads moon extractions, this needs
+// esi-industry.read_corporation_mining.v1 scope and Station_Manager role.
+func (b *fuelBot) loadCorporationExtractions(corp corporation, structures []structureData) ([]extractionData, error) {
+	ctx := corp.ctx()
+	names := make(map[int64]string)
+	for _, structure := range structures {
+		names[structure.CorporationData.StructureId] = structure.UniverseData.Name
+	}
+
+	var (
+		out   []extractionData
+		page  int32 = 1
+		pages int32 = 1
+	)
+	for ; page <= pages; page++ {
+		extractions, resp, err := b.esi.ESI.IndustryApi.GetCorporationCorporationIdMiningExtractions(ctx, corp.ID, &esi.GetCorporationCorporationIdMiningExtractionsOpts{
+			Page: optional.NewInt32(page),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read corporation mining extractions page: %d", page)
+		}
+		pages = esipage.Count(resp)
+
+		for _, extraction := range extractions {
+			moon, err := b.location(ctx, int64(extraction.MoonId))
+			if err != nil {
+				// Log but keep the extraction, its chunk arrives anyway.
+				b.log.Errorw("Error loading moon name",
+					"moon_id", extraction.MoonId,
+					"error", err,
+				)
+				moon.Name = fmt.Sprintf("moon ID: %d", extraction.MoonId)
+			}
+			out = append(out, extractionData{
+				Corporation:     corp,
+				Structure:       names[extraction.StructureId],
+				Moon:            moon.Name,
+				CorporationData: extraction,
+			})
+		}
+	}
+	return out, nil
+}
+
+// loadedExtractions returns extractions from the last successful
+// loadStructures call, sorted by chunk arrival.
+func (b *fuelBot) loadedExtractions() []extractionData {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+	return b.extractions
+}
+
+func sortExtractions(extractions []extractionData) {
+	sort.Slice(extractions, func(i, j int) bool {
+		return extractions[i].CorporationData.ChunkArrivalTime.Before(extractions[j].CorporationData.ChunkArrivalTime)
+	})
+}
+
+// checkExtractions sends notification for chunks arriving within
+// configured time, once per extraction.
+func (b *fuelBot) checkExtractions(extractions []extractionData) {
+	if b.cfg.Extraction.NotifyBefore == 0 {
+		return
+	}
+	for _, extraction := range extractions {
+		id := extraction.CorporationData.StructureId
+		arrival := extraction.CorporationData.ChunkArrivalTime
+		if time.Until(arrival) > b.cfg.Extraction.NotifyBefore || time.Now().After(arrival) {
+			continue
+		}
+
+		b.stateMu.Lock()
+		notified := b.state.ExtractionNotified[id].Equal(arrival)
+		b.stateMu.Unlock()
+		if notified {
+			continue
+		}
+
+		b.log.Infow("Sending extraction message",
+			"structure_id", id,
+			"moon", extraction.Moon,
+			"corporation", extraction.Corporation.Name,
+			"chunk_arrival", arrival,
+		)
+		err := b.send(b.loadedTarget(id), "", b.extractionMessage(extraction))
+		if err != nil {
+			continue
+		}
+
+		b.stateMu.Lock()
+		b.state.ExtractionNotified[id] = arrival
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+func (b *fuelBot) extractionMessage(extraction extractionData) *discordgo.MessageEmbed {
+	return newEmbed("Moon chunk arriving, GO MINE IT!", 0x8a2be2, []*discordgo.MessageEmbedField{extractionField(extraction)})
+}
+
+// extractionsMessage returns as many embeds as needed to list all
+// extractions within Discord embed limits.
+func (b *fuelBot) extractionsMessage(extractions []extractionData) []*discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, extraction := range extractions {
+		fields = append(fields, extractionField(extraction))
+	}
+	if len(fields) == 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "No extractions",
+			Value: "No moon extractions in progress.",
+		})
+	}
+
+	return splitEmbed(newEmbed("Moon extractions", 0x8a2be2, fields))
+}
+
+func extractionField(extraction extractionData) *discordgo.MessageEmbedField {
+	arrival := extraction.CorporationData.ChunkArrivalTime
+	decay := extraction.CorporationData.NaturalDecayTime
+
+	name := extraction.Moon
+	if extraction.Structure != "" {
+		name = fmt.Sprintf("%s (%s)", extraction.Moon, extraction.Structure)
+	}
+	return &discordgo.MessageEmbedField{
+		Name: fmt.Sprintf("%s [%s]", name, extraction.Corporation.Ticker),
+		Value: fmt.Sprintf("**Chunk arrival**: %s (%s) \n**Natural decay**: %s (%s)",
+			formatTime(arrival),
+			arrival,
+			formatTime(decay),
+			decay,
+		),
+	}
+}
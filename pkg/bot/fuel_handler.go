@@ -1,183 +1,20 @@
 package bot
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
 	"github.com/antihax/goesi/esi"
 	"github.com/bwmarrin/discordgo"
 	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 )
 
-type structure struct {
-	Name    string
-	Effects []effect
-}
-
-type effect struct {
-	Category   string
-	Multiplier float64
-}
-
-var structureByType = map[int32]structure{
-	35832: {
-		Name: "Astrahus",
-		Effects: []effect{
-			{
-				Category:   "citadel",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35833: {
-		Name: "Fortizar",
-		Effects: []effect{
-			{
-				Category:   "citadel",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35834: {
-		Name: "Keepstar",
-		Effects: []effect{
-			{
-				Category:   "citadel",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35825: {
-		Name: "Raitaru",
-		Effects: []effect{
-			{
-				Category:   "engineering",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35826: {
-		Name: "Azbel",
-		Effects: []effect{
-			{
-				Category:   "engineering",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35827: {
-		Name: "Sotiyo",
-		Effects: []effect{
-			{
-				Category:   "engineering",
-				Multiplier: 0.75,
-			},
-		},
-	},
-	35835: {
-		Name: "Athanor",
-		Effects: []effect{
-			{
-				Category:   "reaction",
-				Multiplier: 0.8,
-			},
-			{
-				Category:   "reprocessing",
-				Multiplier: 0.8,
-			},
-		},
-	},
-	35836: {
-		Name: "Tatara",
-		Effects: []effect{
-			{
-				Category:   "reaction",
-				Multiplier: 0.75,
-			},
-			{
-				Category:   "reprocessing",
-				Multiplier: 0.75,
-			},
-		},
-	},
-}
-
-type service struct {
-	Name        string
-	FuelPerHour uint32
-}
-
-var serviceByCategory = map[string][]service{
-	"citadel": {
-		{
-			Name:        "Clone Bay",
-			FuelPerHour: 10,
-		},
-		{
-			Name:        "Market",
-			FuelPerHour: 40,
-		},
-	},
-	"engineering": {
-		{
-			// All of these 3 services are actually 1 module with 1 fuel
-			// fuel consumption.
-			Name: "Blueprint Copying",
-			// Name:        "Time Efficiency Research",
-			// Name:        "Material Efficiency Research",
-			FuelPerHour: 12,
-		},
-		{
-			Name:        "Invention",
-			FuelPerHour: 12, // Hyasoda module is 10.
-		},
-		{
-			Name:        "Manufacturing (Standard)",
-			FuelPerHour: 12,
-		},
-		{
-			Name:        "Manufacturing (Capital)",
-			FuelPerHour: 24,
-		},
-		{
-			Name:        "Manufacturing (Supercapital)",
-			FuelPerHour: 36,
-		},
-	},
-	"reaction": {
-		{
-			Name:        "Biochemical Reactions",
-			FuelPerHour: 15,
-		},
-		{
-			Name:        "Composite Reactions",
-			FuelPerHour: 15,
-		},
-		{
-			Name:        "Hybrid Reactions",
-			FuelPerHour: 15,
-		},
-	},
-	"reprocessing": {
-		{
-			Name:        "Reprocessing",
-			FuelPerHour: 10,
-		},
-	},
-	"resource processing": {
-		{
-			Name:        "Moon Drilling",
-			FuelPerHour: 5,
-		},
-	},
-}
-
 // messageFuelHandler will be called every time a new
 // message is created on any channel that the autenticated bot has access to.
 func (b *fuelBot) messageFuelHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
@@ -186,60 +23,110 @@ func (b *fuelBot) messageFuelHandler(s *discordgo.Session, m *discordgo.MessageC
 		return
 	}
 
-	// check if the message is "!fuel"
-	if m.Content == "!fuel" {
-		// Find the channel that the message came from.
-		c, err := s.State.Channel(m.ChannelID)
+	// check if the message is "!fuel", "!fuel stock", "!fuel plan <days>"
+	// or "!fuel moons"
+	args := strings.Fields(m.Content)
+	if len(args) == 0 || args[0] != "!fuel" {
+		return
+	}
+
+	// Find the channel that the message came from.
+	c, err := s.State.Channel(m.ChannelID)
+	if err != nil {
+		// Could not find channel.
+		b.log.Errorw("error finding channel_id to respond to !fuel message", "err", err)
+		return
+	}
+
+	embeds, err := b.textCommand(args[1:])
+	if err != nil {
+		b.log.Errorw("error responding to command", "command", m.Content, "err", err)
+		return
+	}
+	b.log.Infow("Sending response to command",
+		"channel_id", c.ID,
+		"command", m.Content,
+	)
+	for _, embed := range embeds {
+		_, err = b.discord.ChannelMessageSendEmbed(c.ID, embed)
 		if err != nil {
-			// Could not find channel.
-			b.log.Errorw("error finding channel_id to respond to !fuel message", "err", err)
+			b.log.Errorw("error sending discord message", "err", err)
 			return
 		}
+	}
+}
 
+// textCommand returns response to "!fuel" text command with args.
+func (b *fuelBot) textCommand(args []string) ([]*discordgo.MessageEmbed, error) {
+	if len(args) == 0 {
 		structs, err := b.loadStructures()
 		if err != nil {
-			b.log.Errorw("error loading structure information", "err", err)
-			return
+			return nil, errors.Wrap(err, "error loading structure information")
 		}
-		b.log.Infow("Sending response to !fuel command",
-			"channel_id", b.channelID,
-		)
-		_, err = b.discord.ChannelMessageSendEmbed(c.ID, b.allStructuresMessage(structs))
+		return b.allStructuresMessage(structs, b.loadedStarbases()), nil
+	}
+
+	switch args[0] {
+	case "stock":
+		return b.stockReport()
+	case "moons":
+		_, err := b.loadStructures()
 		if err != nil {
-			b.log.Errorw("error sending discord message", "err", err)
-			return
+			return nil, errors.Wrap(err, "error loading structure information")
+		}
+		return b.extractionsMessage(b.loadedExtractions()), nil
+	case "plan":
+		days := defaultPlanDays
+		if len(args) > 1 {
+			var err error
+			days, err = strconv.Atoi(args[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid number of days: %s", args[1])
+			}
 		}
+		plan, err := b.Plan(days)
+		if err != nil {
+			return nil, err
+		}
+		return b.planMessage(plan), nil
 	}
+	return nil, errors.Errorf("unknown command: %s", args[0])
 }
 
-func (b *fuelBot) allStructuresMessage(structures []structureData) *discordgo.MessageEmbed {
+// allStructuresMessage returns as many embeds as needed to list all
+// structures and starbases within Discord embed limits, "Total fuel"
+// is always in the last one.
+func (b *fuelBot) allStructuresMessage(structures []structureData, starbases []starbaseData) []*discordgo.MessageEmbed {
 	var (
 		fields    []*discordgo.MessageEmbedField
 		fuelTotal float64
+		gasTotal  float64
 	)
-
+	// Only show owner when there is more than one corporation.
+	corps := make(map[int32]bool)
 	for _, structureData := range structures {
-		structureType := structureByTypeID(structureData.CorporationData.TypeId)
-		// Add symbols for time ranges for fuel remaining. Green = OK
-		symbol := ":green_square:"
-		// < 7 days = orange
-		if time.Until(structureData.CorporationData.FuelExpires) < 7*time.Hour*24 {
-			symbol = ":orange_square:"
-		}
-		// < 1 day = red
-		if time.Until(structureData.CorporationData.FuelExpires) < 1*time.Hour*24 {
-			symbol = ":red_square:"
-		}
+		corps[structureData.Corporation.ID] = true
+	}
+	for _, starbase := range starbases {
+		corps[starbase.Corporation.ID] = true
+	}
+	showOwner := len(corps) > 1
 
+	for _, structureData := range structures {
+		structureType := b.structureByTypeID(structureData.CorporationData.TypeId)
 		field := &discordgo.MessageEmbedField{
 			Name: fmt.Sprintf("%s %s (%s)",
-				symbol,
+				fuelSymbol(structureData.CorporationData.FuelExpires),
 				structureData.UniverseData.Name,
 				structureType.Name,
 			),
 		}
+		if showOwner {
+			field.Name = fmt.Sprintf("%s [%s]", field.Name, structureData.Corporation.Ticker)
+		}
 		fuelPerDay := b.structureFuelPerDay(structureData, structureType)
 		fuelTotal += fuelPerDay
+		gasTotal += float64(structureType.GasPerHour) * 24
 
 		if structureData.CorporationData.FuelExpires.IsZero() {
 			field.Value = "`UNFUELLED`"
@@ -251,7 +138,17 @@ func (b *fuelBot) allStructuresMessage(structures []structureData) *discordgo.Me
 				formatServices(structureData.CorporationData.Services),
 				fuelPerDay,
 			)
+			field.Value += formatFuelBay(structureData, fuelPerDay)
+			field.Value += formatGas(structureData, structureType)
+			field.Value += b.formatOzone(structureData, structureType)
 		}
+		field.Value += b.formatAck(structureData.CorporationData.StructureId)
+		fields = append(fields, field)
+	}
+	for _, starbase := range starbases {
+		fuelTotal += starbase.FuelPerDay()
+		field := starbaseField(starbase, showOwner)
+		field.Value += b.formatAck(starbase.CorporationData.StarbaseId)
 		fields = append(fields, field)
 	}
 
@@ -259,58 +156,67 @@ func (b *fuelBot) allStructuresMessage(structures []structureData) *discordgo.Me
 	dailyFuelMsg := fmt.Sprintf("**Daily**: %.0f", fuelTotal)
 	monthlyFuelMsg := fmt.Sprintf("**Monthly**: %.0f", fuelTotal*month)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	fuelPrices, err := b.estFuelPrice(ctx)
-	if err != nil {
-		b.log.Errorw("unable to estimate fuel prices", "error", err)
-	}
-	fuelDailyPrices := formatFuelPrices(fuelTotal, fuelPrices)
-	fuelMonthlyPrices := formatFuelPrices(fuelTotal*month, fuelPrices)
+	fuelPrices := b.prices.Snapshot()
+	fuelDailyPrices := formatFuelPrices(fuelTotal, fuelPrices.Prices)
+	fuelMonthlyPrices := formatFuelPrices(fuelTotal*month, fuelPrices.Prices)
 
 	finishedDailyMsg := fmt.Sprintf("%s %s", dailyFuelMsg, fuelDailyPrices)
 	finishedMonthlyMsg := fmt.Sprintf("%s %s", monthlyFuelMsg, fuelMonthlyPrices)
+	if gasTotal > 0 {
+		finishedMonthlyMsg += fmt.Sprintf("**Magmatic gas**: %.0f daily%s, %.0f monthly%s\n",
+			gasTotal,
+			formatGasPrice(gasTotal, fuelPrices.Prices),
+			gasTotal*month,
+			formatGasPrice(gasTotal*month, fuelPrices.Prices),
+		)
+	}
 
 	fields = append(fields, &discordgo.MessageEmbedField{
 		Name: ":ice_cube: Total fuel",
-		Value: fmt.Sprintf("%s \n%s",
+		Value: fmt.Sprintf("%s \n%s%s",
 			finishedDailyMsg,
 			finishedMonthlyMsg,
+			formatPricesAge(fuelPrices),
 		),
 	})
 
-	return &discordgo.MessageEmbed{
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: "https://i.imgur.com/pKEZq6F.png",
-		},
-		Color:     0x00ff00,
-		Fields:    fields,
-		Timestamp: time.Now().Format(time.RFC3339), // Discord wants ISO8601; RFC3339 is an extension of ISO8601 and should be completely compatible.
-		Title:     "Feeding status",
+	return splitEmbed(newEmbed("Feeding status", 0x00ff00, fields))
+}
+
+// fuelSymbol returns symbol for time ranges for fuel remaining.
+func fuelSymbol(expires time.Time) string {
+	switch {
+	// < 1 day = red
+	case time.Until(expires) < 1*time.Hour*24:
+		return ":red_square:"
+	// < 7 days = orange
+	case time.Until(expires) < 7*time.Hour*24:
+		return ":orange_square:"
 	}
+	// Green = OK
+	return ":green_square:"
 }
 
 const serviceStateOnline = "online"
 
-func (b *fuelBot) structureFuelPerDay(structure structureData, structureType structure) float64 {
-	var acc float64
+func (b *fuelBot) structureFuelPerDay(structure structureData, structureType sde.Structure) float64 {
+	// Fuel burned regardless of services, eg. by Metenox moon drill.
+	acc := float64(structureType.FuelPerHour)
 
 	for _, service := range structure.CorporationData.Services {
 		if service.State != serviceStateOnline {
 			continue
 		}
 
-		for serviceCategory, services := range serviceByCategory {
+		for serviceCategory, services := range b.sde.Services {
 			for _, baseService := range services {
 				if service.Name != baseService.Name {
 					continue
 				}
 
-				var mul float64 = 1
-				for _, effect := range structureType.Effects {
-					if effect.Category == serviceCategory {
-						mul = effect.Multiplier
-					}
+				mul := multiplier(structureType.Effects, serviceCategory)
+				for _, typeID := range structure.Rigs {
+					mul *= multiplier(b.sde.Rigs[typeID].Effects, serviceCategory)
 				}
 				acc += float64(baseService.FuelPerHour) * mul
 			}
@@ -319,80 +225,46 @@ func (b *fuelBot) structureFuelPerDay(structure structureData, structureType str
 	return acc * 24
 }
 
+// multiplier returns fuel multiplier of effects for service category.
+func multiplier(effects []sde.Effect, category string) float64 {
+	var mul float64 = 1
+	for _, effect := range effects {
+		if effect.Category == category {
+			mul *= effect.Multiplier
+		}
+	}
+	return mul
+}
+
 const (
 	heliumFuelBlockTypeID   = 4247 // "Helium Fuel Block"
 	hydrogenFuelBlockTypeID = 4246 // "Hydrogen Fuel Block"
 	nitrogenFuelBlockTypeID = 4051 // "Nitrogen Fuel Block"
 	oxygenFuelBlockTypeID   = 4312 // "Oxygen Fuel Block"
-
-	theForgeRegionID       = 10000002 // The Forge (Jita)
-	eveMarketerPriceAPIURL = "https://api.evemarketer.com/ec/marketstat/json"
 )
 
-type eveMarketerBuySell struct {
-	Buy  eveMarketerItemStats `json:"buy"`
-	Sell eveMarketerItemStats `json:"sell"`
+var fuelBlockTypeIDs = []int32{
+	heliumFuelBlockTypeID,
+	hydrogenFuelBlockTypeID,
+	nitrogenFuelBlockTypeID,
+	oxygenFuelBlockTypeID,
 }
 
-type eveMarketerItemStats struct {
-	Query struct {
-		TypeIDs []int32 `json:"types"`
-	} `json:"forQuery"`
-	Volume          float64 `json:"volume"`
-	WeightedAverage float64 `json:"wavg"`
-	Average         float64 `json:"avg"`
-	Variance        float64 `json:"variance"`
-	StdDev          float64 `json:"stdDev"`
-	Median          float64 `json:"median"`
-	FivePercent     float64 `json:"fivePercent"`
-	Max             float64 `json:"max"`
-	Min             float64 `json:"min"`
+// PriceTypeIDs returns type IDs of items the bot needs prices of.
+func PriceTypeIDs() []int32 {
+	return append([]int32{magmaticGasTypeID}, fuelBlockTypeIDs...)
 }
 
-func (b *fuelBot) estFuelPrice(ctx context.Context) (map[int32]float64, error) {
-	typeIDs := []int32{
-		heliumFuelBlockTypeID,
-		hydrogenFuelBlockTypeID,
-		nitrogenFuelBlockTypeID,
-		oxygenFuelBlockTypeID,
-	}
-
-	eveMarketerURL, err := url.Parse(eveMarketerPriceAPIURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "error parsing evemarketer api url")
-	}
-	query := eveMarketerURL.Query()
-	query.Add("regionlimit", fmt.Sprint(theForgeRegionID))
-	for _, typeID := range typeIDs {
-		query.Add("typeid", fmt.Sprint(typeID))
-	}
-	eveMarketerURL.RawQuery = query.Encode()
-
-	resp, err := b.httpClient.Get(eveMarketerURL.String())
-	if err != nil {
-		b.log.Errorw("error calling evemarketer API", "err", err, "url", eveMarketerURL.String())
-		return nil, errors.Wrap(err, "error calling evemarketer API")
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		b.log.Errorw("error reading evemarketer API response", "err", err)
-		return nil, errors.Wrap(err, "error reading evemarketer API response")
-	}
-
-	var eveMarketerResp []eveMarketerBuySell
-	err = json.Unmarshal(body, &eveMarketerResp)
-	if err != nil {
-		b.log.Errorw("error parsing evemarketer json", "err", err, "body", string(body))
-		return nil, errors.Wrap(err, "error parsing evemarketer json")
-	}
-
-	var out = make(map[int32]float64)
-	for _, eveMarketerRespItem := range eveMarketerResp {
-		typeID := eveMarketerRespItem.Sell.Query.TypeIDs[0]
-		out[typeID] = eveMarketerRespItem.Sell.WeightedAverage
+// formatPricesAge returns when the prices were loaded, so it is visible
+// when last known prices are used because the provider fails.
+func formatPricesAge(snapshot price.Snapshot) string {
+	if snapshot.UpdatedAt.IsZero() {
+		return ""
 	}
-	return out, nil
+	return fmt.Sprintf("*Prices as of %s (%s)*",
+		humanize.Time(snapshot.UpdatedAt),
+		snapshot.UpdatedAt.UTC().Format("2006-01-02 15:04 MST"),
+	)
 }
 
 func formatFuelPrices(blocks float64, fuelPrices map[int32]float64) string {
@@ -400,26 +272,17 @@ func formatFuelPrices(blocks float64, fuelPrices map[int32]float64) string {
 		return "Error fetching prices."
 	}
 
-	// names must be indexed same as prices.
+	// names must be indexed same as fuelBlockTypeIDs.
 	names := []string{
 		"[He]",
 		"[H]",
 		"[N]",
 		"[O]",
 	}
-	prices := []float64{
-		// Helium
-		fuelPrices[heliumFuelBlockTypeID] * blocks,
-		// Hydrogen
-		fuelPrices[hydrogenFuelBlockTypeID] * blocks,
-		// Nitrogen
-		fuelPrices[nitrogenFuelBlockTypeID] * blocks,
-		// Oxygen
-		fuelPrices[oxygenFuelBlockTypeID] * blocks,
-	}
-	var minPriceIdx int
-	for i, price := range prices {
-		if price < prices[minPriceIdx] {
+	minPriceIdx := -1
+	for i, typeID := range fuelBlockTypeIDs {
+		price, ok := fuelPrices[typeID]
+		if ok && (minPriceIdx == -1 || price < fuelPrices[fuelBlockTypeIDs[minPriceIdx]]) {
 			minPriceIdx = i
 		}
 	}
@@ -427,14 +290,21 @@ func formatFuelPrices(blocks float64, fuelPrices map[int32]float64) string {
 	var b strings.Builder
 	b.WriteRune('\n')
 
-	for i, price := range prices {
+	for i, typeID := range fuelBlockTypeIDs {
+		price, ok := fuelPrices[typeID]
+		if !ok {
+			// Type the provider has never had price for.
+			b.WriteString(names[i])
+			b.WriteString(" n/a\n")
+			continue
+		}
 		// Lowest price is bold.
 		if i == minPriceIdx {
 			b.WriteString("**")
 		}
 		b.WriteString(names[i])
 		b.WriteRune(' ')
-		b.WriteString(humanize.CommafWithDigits(price, 0))
+		b.WriteString(humanize.CommafWithDigits(price*blocks, 0))
 		// Lowest price is bold.
 		if i == minPriceIdx {
 			b.WriteString("**")
@@ -446,16 +316,55 @@ func formatFuelPrices(blocks float64, fuelPrices map[int32]float64) string {
 	return b.String()
 }
 
-func structureByTypeID(typeID int32) structure {
-	structureType, ok := structureByType[typeID]
+func (b *fuelBot) structureByTypeID(typeID int32) sde.Structure {
+	structureType, ok := b.sde.Structures[typeID]
 	if !ok {
-		return structure{
+		return sde.Structure{
 			Name: fmt.Sprintf("unknown structure type ID: %d", typeID),
 		}
 	}
 	return structureType
 }
 
+// fuelMismatchTolerance is how much the fuel bay contents may differ from
+// the estimate based on services before it is flagged.
+const fuelMismatchTolerance = 0.1
+
+// formatFuelBay returns fuel blocks in the fuel bay and flags when they
+// do not match fuel expiration estimated from services.
+func formatFuelBay(structure structureData, fuelPerDay float64) string {
+	if structure.FuelBay == nil {
+		return ""
+	}
+	blocks := fuelBayBlocks(structure)
+	msg := fmt.Sprintf(" \n **Fuel bay**: %s blocks", humanize.Comma(blocks))
+	if fuelPerDay == 0 {
+		return msg
+	}
+
+	estimate := time.Duration(float64(blocks) / fuelPerDay * float64(24*time.Hour))
+	remaining := time.Until(structure.CorporationData.FuelExpires)
+	msg += fmt.Sprintf(" (%s)", formatDuration(estimate))
+
+	diff := estimate - remaining
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > 24*time.Hour && float64(diff) > float64(remaining)*fuelMismatchTolerance {
+		msg += " :warning: does not match services estimate"
+	}
+	return msg
+}
+
+// fuelBayBlocks returns number of fuel blocks in structure fuel bay.
+func fuelBayBlocks(structure structureData) int64 {
+	var blocks int64
+	for _, typeID := range fuelBlockTypeIDs {
+		blocks += structure.FuelBay[typeID]
+	}
+	return blocks
+}
+
 func formatServices(services []esi.GetCorporationsCorporationIdStructuresService) string {
 	var builder strings.Builder
 	for i, service := range services {
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
+	"github.com/antihax/goesi/esi"
+)
+
+func TestStructureFuelPerDay(t *testing.T) {
+	b := &fuelBot{
+		sde: &sde.Data{
+			Services: map[string][]sde.Service{
+				"reprocessing": {{Name: "Reprocessing", FuelPerHour: 10}},
+			},
+			Rigs: map[int32]sde.Rig{
+				37000: {Effects: []sde.Effect{{Category: "reprocessing", Multiplier: 0.5}}},
+			},
+		},
+	}
+	structureType := sde.Structure{
+		Effects: []sde.Effect{{Category: "reprocessing", Multiplier: 0.8}},
+	}
+	structure := structureData{
+		CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{
+			Services: []esi.GetCorporationsCorporationIdStructuresService{
+				{Name: "Reprocessing", State: serviceStateOnline},
+			},
+		},
+	}
+
+	if fuel := b.structureFuelPerDay(structure, structureType); fuel != 192 {
+		t.Errorf("expected 192 blocks per day without rigs, got: %.1f", fuel)
+	}
+	structure.Rigs = []int32{37000}
+	if fuel := b.structureFuelPerDay(structure, structureType); fuel != 96 {
+		t.Errorf("expected 96 blocks per day with rig, got: %.1f", fuel)
+	}
+}
+
+func TestFormatFuelPricesMissingType(t *testing.T) {
+	prices := map[int32]float64{
+		heliumFuelBlockTypeID:   30,
+		nitrogenFuelBlockTypeID: 20,
+		oxygenFuelBlockTypeID:   40,
+	}
+	expected := "\n[He] 300 ISK\n[H] n/a\n**[N] 200** ISK\n[O] 400 ISK\n"
+	if out := formatFuelPrices(10, prices); out != expected {
+		t.Errorf("expected %q, got: %q", expected, out)
+	}
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+)
+
+const magmaticGasTypeID = 81143 // "Magmatic Gas"
+
+// gasExpires returns when structure runs out of magmatic gas, zero time
+// when it does not burn gas or its fuel bay contents are unknown.
+func gasExpires(structure structureData, structureType sde.Structure) time.Time {
+	if structureType.GasPerHour == 0 || structure.FuelBay == nil {
+		return time.Time{}
+	}
+	hours := float64(structure.FuelBay[magmaticGasTypeID]) / float64(structureType.GasPerHour)
+	return time.Now().Add(time.Duration(hours * float64(time.Hour)))
+}
+
+// checkGas sends notification for Metenox moon drills running out of
+// magmatic gas, independently of fuel block notifications.
+func (b *fuelBot) checkGas(structs []structureData) {
+	for _, structure := range structs {
+		structureType := b.structureByTypeID(structure.CorporationData.TypeId)
+		expires := gasExpires(structure, structureType)
+		target := structureTarget(structure, structureType.Name)
+		threshold, notify := b.shouldNotify(resourceGas, target, expires)
+		if !notify {
+			continue
+		}
+		b.log.Infow("Sending gas message",
+			"structure_id", structure.CorporationData.StructureId,
+			"structure_name", structure.UniverseData.Name,
+			"corporation", structure.Corporation.Name,
+			"threshold", threshold.Before,
+		)
+		err := b.sendAlert(target, threshold, b.gasMessage(structure, expires, threshold))
+		if err != nil {
+			continue
+		}
+		b.setWasNotified(resourceGas, structure.CorporationData.StructureId, threshold)
+	}
+}
+
+func (b *fuelBot) gasMessage(structure structureData, expires time.Time, threshold Threshold) *discordgo.MessageEmbed {
+	return newEmbed("Moon drill running out of magmatic gas, FEED IT!", threshold.Color, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: fmt.Sprintf("`%s`", structure.UniverseData.Name),
+		},
+		{
+			Name:  "When?!",
+			Value: fmt.Sprintf("%s (%s)", formatTime(expires), expires),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", structure.Corporation.Name, structure.Corporation.Ticker),
+		},
+	})
+}
+
+// formatGas returns magmatic gas in the fuel bay and how long it lasts.
+func formatGas(structure structureData, structureType sde.Structure) string {
+	if structureType.GasPerHour == 0 {
+		return ""
+	}
+	msg := fmt.Sprintf(" \n **Gas per day**: %d", structureType.GasPerHour*24)
+	expires := gasExpires(structure, structureType)
+	if expires.IsZero() {
+		return msg
+	}
+	return msg + fmt.Sprintf(" \n **Magmatic gas**: %s (%s)",
+		humanize.Comma(structure.FuelBay[magmaticGasTypeID]),
+		formatDuration(time.Until(expires)),
+	)
+}
+
+// formatGasPrice returns price of magmatic gas units, empty when there
+// is no gas or no price.
+func formatGasPrice(units float64, prices map[int32]float64) string {
+	price, ok := prices[magmaticGasTypeID]
+	if units == 0 || !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%s ISK)", humanize.CommafWithDigits(units*price, 0))
+}
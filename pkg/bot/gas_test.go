@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"go.uber.org/zap"
+)
+
+func TestGasExpires(t *testing.T) {
+	metenox := sde.Structure{Name: "Metenox Moon Drill", FuelPerHour: 5, GasPerHour: 200}
+
+	if !gasExpires(structureData{}, metenox).IsZero() {
+		t.Errorf("expected unknown gas without fuel bay contents")
+	}
+	structure := structureData{FuelBay: map[int32]int64{magmaticGasTypeID: 4800}}
+	if !gasExpires(structure, sde.Structure{Name: "Astrahus"}).IsZero() {
+		t.Errorf("expected no gas expiration for structure not burning gas")
+	}
+	remaining := time.Until(gasExpires(structure, metenox))
+	if remaining < 23*time.Hour || remaining > 24*time.Hour {
+		t.Errorf("expected 24 hours of gas, got: %s", remaining)
+	}
+}
+
+func TestRefuelResetsGas(t *testing.T) {
+	b := &fuelBot{
+		log:          zap.NewNop().Sugar(),
+		sde:          &sde.Data{},
+		notifier:     &recordNotifier{},
+		cfg:          Config{RefuelDetection: time.Hour},
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	structure := structureData{
+		CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 1, FuelExpires: time.Now().Add(time.Hour)},
+	}
+	b.checkRefuels([]structureData{structure}, nil)
+	b.state.GasNotified[1] = state.Notification{At: time.Now()}
+	b.state.OzoneNotified[1] = state.Notification{At: time.Now()}
+
+	structure.CorporationData.FuelExpires = time.Now().Add(10 * 24 * time.Hour)
+	b.checkRefuels([]structureData{structure}, nil)
+	if _, ok := b.state.GasNotified[1]; ok {
+		t.Error("refuel should reset gas notification")
+	}
+	if _, ok := b.state.OzoneNotified[1]; ok {
+		t.Error("refuel should reset ozone notification")
+	}
+}
@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// notificationMaxAge is how old notification can be to be forwarded,
+	// so the first start does not flood the channel with old ones.
+	notificationMaxAge = 24 * time.Hour
+	// notificationRetention is how long forwarded notification IDs are
+	// remembered, ESI returns notifications for the last 30 days at most.
+	notificationRetention = 30 * 24 * time.Hour
+)
+
+// NotificationConfig configures forwarding of in-game notifications.
+type NotificationConfig struct {
+	// Types of notifications to forward, empty disables forwarding.
+	Types []string
+}
+
+// DefaultNotificationTypes are notification types forwarded by default.
+var DefaultNotificationTypes = []string{
+	"StructureFuelAlert",
+	"StructureUnderAttack",
+	"StructureLostShields",
+	"StructureLostArmor",
+	"StructureServicesOffline",
+	"StructureWentLowPower",
+	"StructureDestroyed",
+}
+
+// notificationStyles holds embed title and color of known notification
+// types, critical ones are sent within quiet hours.
+var notificationStyles = map[string]struct {
+	Title    string
+	Color    int
+	Critical bool
+}{
+	"StructureFuelAlert":       {Title: "Structure fuel alert, FEED IT!", Color: 0xffa500},
+	"StructureUnderAttack":     {Title: "Structure under attack, DEFEND IT!", Color: 0xff0000, Critical: true},
+	"StructureLostShields":     {Title: "Structure lost shields, reinforced!", Color: 0xff0000, Critical: true},
+	"StructureLostArmor":       {Title: "Structure lost armor, reinforced!", Color: 0xff0000, Critical: true},
+	"StructureServicesOffline": {Title: "Structure services went offline", Color: 0xffa500},
+	"StructureWentLowPower":    {Title: "Structure went low power", Color: 0xffa500, Critical: true},
+	"StructureWentHighPower":   {Title: "Structure went high power", Color: 0x00ff00},
+	"StructureDestroyed":       {Title: "Structure destroyed, RIP", Color: 0x000000},
+	"StructureAnchoring":       {Title: "Structure anchoring", Color: 0x00bfff},
+	"StructureUnanchoring":     {Title: "Structure unanchoring", Color: 0x00bfff},
+	"StructureOnline":          {Title: "Structure online", Color: 0x00ff00},
+}
+
+type notification = esi.GetCharactersCharacterIdNotifications200Ok
+
+// notificationBody holds fields of structure notification YAML bodies
+// shown in the embed, other fields are ignored.
+type notificationBody struct {
+	SolarsystemID   int32 `yaml:"solarsystemID"`
+	StructureID     int64 `yaml:"structureID"`
+	StructureTypeID int32 `yaml:"structureTypeID"`
+	// TimeLeft is in 100 nanosecond ticks.
+	TimeLeft          int64     `yaml:"timeLeft"`
+	ShieldPercentage  float64   `yaml:"shieldPercentage"`
+	ArmorPercentage   float64   `yaml:"armorPercentage"`
+	HullPercentage    float64   `yaml:"hullPercentage"`
+	CorpName          string    `yaml:"corpName"`
+	AllianceName      string    `yaml:"allianceName"`
+	ListOfTypesAndQty [][]int32 `yaml:"listOfTypesAndQty"`
+}
+
+func parseNotification(text string) (notificationBody, error) {
+	var body notificationBody
+	err := yaml.Unmarshal([]byte(text), &body)
+	return body, errors.Wrap(err, "error parsing notification body")
+}
+
+// checkNotifications forwards new notifications of configured types
+// received by character of each corporation.
+func (b *fuelBot) checkNotifications() {
+	if len(b.cfg.Notification.Types) == 0 {
+		return
+	}
+	corps, err := b.corporations()
+	if err != nil {
+		b.log.Errorw("Error loading corporations for notifications", "error", err)
+		return
+	}
+	for _, corp := range corps {
+		notifications, _, err := b.esi.ESI.CharacterApi.GetCharactersCharacterIdNotifications(corp.ctx(), corp.CharacterID, nil)
+		if err != nil {
+			// Log but continue, older tokens may be missing the scope.
+			b.log.Errorw("Error loading character notifications",
+				"character", corp.CharacterName,
+				"error", err,
+			)
+			continue
+		}
+		sort.Slice(notifications, func(i, j int) bool {
+			return notifications[i].Timestamp.Before(notifications[j].Timestamp)
+		})
+		for _, n := range notifications {
+			b.forwardNotification(corp, n)
+		}
+	}
+
+	b.stateMu.Lock()
+	for id, at := range b.state.SeenNotifications {
+		if time.Since(at) > notificationRetention {
+			delete(b.state.SeenNotifications, id)
+		}
+	}
+	b.saveState()
+	b.stateMu.Unlock()
+}
+
+func (b *fuelBot) forwardNotification(corp corporation, n notification) {
+	if !b.notificationType(n.Type_) || time.Since(n.Timestamp) > notificationMaxAge {
+		return
+	}
+	b.stateMu.Lock()
+	_, seen := b.state.SeenNotifications[n.NotificationId]
+	b.stateMu.Unlock()
+	if seen {
+		return
+	}
+
+	body, err := parseNotification(n.Text)
+	if err != nil {
+		// Log but still forward, the embed just has less details.
+		b.log.Errorw("Error parsing notification",
+			"notification_id", n.NotificationId,
+			"type", n.Type_,
+			"error", err,
+		)
+	}
+	b.log.Infow("Sending notification message",
+		"notification_id", n.NotificationId,
+		"type", n.Type_,
+		"corporation", corp.Name,
+	)
+	err = b.sendMessage(b.loadedTarget(body.StructureID), notify.Message{
+		Embed:    b.notificationMessage(corp, n, body),
+		Critical: b.notificationCritical(n.Type_, body),
+	})
+	if err != nil {
+		return
+	}
+
+	b.stateMu.Lock()
+	b.state.SeenNotifications[n.NotificationId] = n.Timestamp
+	b.saveState()
+	b.stateMu.Unlock()
+}
+
+// notificationCritical checks if notification is sent within quiet
+// hours: attacks and low power are, fuel alert only when the structure
+// fuel is within critical threshold.
+func (b *fuelBot) notificationCritical(notificationType string, body notificationBody) bool {
+	if notificationType != "StructureFuelAlert" {
+		return notificationStyles[notificationType].Critical
+	}
+	for _, structure := range b.loadedStructures() {
+		if structure.CorporationData.StructureId == body.StructureID {
+			return b.fuelCritical(b.structureTarget(structure), structure.CorporationData.FuelExpires)
+		}
+	}
+	return false
+}
+
+func (b *fuelBot) notificationType(notificationType string) bool {
+	for _, t := range b.cfg.Notification.Types {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *fuelBot) notificationMessage(corp corporation, n notification, body notificationBody) *discordgo.MessageEmbed {
+	style, ok := notificationStyles[n.Type_]
+	if !ok {
+		style.Title = n.Type_
+		style.Color = 0xff0000
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: b.notificationWhere(corp, body),
+		},
+		{
+			Name:  "When?!",
+			Value: fmt.Sprintf("%s (%s)", formatTime(n.Timestamp), n.Timestamp),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", corp.Name, corp.Ticker),
+		},
+	}
+	if details := b.notificationDetails(n, body); details != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "What?!",
+			Value: details,
+		})
+	}
+
+	return newEmbed(style.Title, style.Color, fields)
+}
+
+// notificationWhere returns structure name and solar system, loaded
+// structures are preferred so ESI is not called.
+func (b *fuelBot) notificationWhere(corp corporation, body notificationBody) string {
+	structureType := b.structureByTypeID(body.StructureTypeID)
+	for _, structure := range b.loadedStructures() {
+		if structure.CorporationData.StructureId == body.StructureID {
+			return fmt.Sprintf("`%s` (%s)", structure.UniverseData.Name, structureType.Name)
+		}
+	}
+	if body.SolarsystemID == 0 {
+		return "`unknown`"
+	}
+	system, err := b.solarSystem(corp.ctx(), body.SolarsystemID)
+	if err != nil {
+		b.log.Errorw("Error loading notification solar system", "error", err)
+		return fmt.Sprintf("`%s`", structureType.Name)
+	}
+	return fmt.Sprintf("`%s` in %s", structureType.Name, system.Name)
+}
+
+// notificationDetails returns notification specific information,
+// empty when there is none.
+func (b *fuelBot) notificationDetails(n notification, body notificationBody) string {
+	var details []string
+	if body.CorpName != "" {
+		attacker := body.CorpName
+		if body.AllianceName != "" {
+			attacker = fmt.Sprintf("%s (%s)", body.CorpName, body.AllianceName)
+		}
+		details = append(details, fmt.Sprintf("**Attacker**: %s", attacker))
+	}
+	if body.ShieldPercentage != 0 || body.ArmorPercentage != 0 || body.HullPercentage != 0 {
+		details = append(details, fmt.Sprintf("**Shield**: %.0f%% **Armor**: %.0f%% **Hull**: %.0f%%",
+			body.ShieldPercentage,
+			body.ArmorPercentage,
+			body.HullPercentage,
+		))
+	}
+	if body.TimeLeft != 0 {
+		// TimeLeft is in 100 nanosecond ticks.
+		end := n.Timestamp.Add(time.Duration(body.TimeLeft) * 100)
+		details = append(details, fmt.Sprintf("**Timer end**: %s (%s)", formatTime(end), end))
+	}
+	for _, typeAndQty := range body.ListOfTypesAndQty {
+		if len(typeAndQty) != 2 {
+			continue
+		}
+		details = append(details, fmt.Sprintf("**%s**: %s", b.typeName(typeAndQty[1]), humanize.Comma(int64(typeAndQty[0]))))
+	}
+	return strings.Join(details, " \n")
+}
+
+// typeName returns name of fuel types the bot knows, type ID otherwise.
+func (b *fuelBot) typeName(typeID int32) string {
+	if name, ok := fuelBlockNames[typeID]; ok {
+		return name
+	}
+	switch typeID {
+	case magmaticGasTypeID:
+		return "Magmatic Gas"
+	case liquidOzoneTypeID:
+		return "Liquid Ozone"
+	case strontiumTypeID:
+		return "Strontium Clathrates"
+	}
+	return fmt.Sprintf("type ID %d", typeID)
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
+	"github.com/antihax/goesi/esi"
+)
+
+func TestParseNotification(t *testing.T) {
+	text := `allianceID: 99000001
+allianceLinkData:
+- showinfo
+- 16159
+- 99000001
+allianceName: Test Alliance
+armorPercentage: 100.0
+charID: 90000001
+corpLinkData:
+- showinfo
+- 2
+- 98000001
+corpName: Test Corp
+hullPercentage: 100.0
+shieldPercentage: 42.519
+solarsystemID: 30000142
+structureID: &id001 1021121988766
+structureShowInfoData:
+- showinfo
+- 35832
+- *id001
+structureTypeID: 35832
+`
+	body, err := parseNotification(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.StructureID != 1021121988766 || body.SolarsystemID != 30000142 || body.StructureTypeID != 35832 {
+		t.Errorf("unexpected structure: %+v", body)
+	}
+	if body.CorpName != "Test Corp" || body.AllianceName != "Test Alliance" || body.ShieldPercentage != 42.519 {
+		t.Errorf("unexpected attacker: %+v", body)
+	}
+
+	body, err = parseNotification("listOfTypesAndQty:\n- - 307\n  - 4246\nsolarsystemID: 30000142\nstructureID: 1021121988766\nstructureTypeID: 35832\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body.ListOfTypesAndQty) != 1 || body.ListOfTypesAndQty[0][0] != 307 || body.ListOfTypesAndQty[0][1] != 4246 {
+		t.Errorf("unexpected fuel: %+v", body.ListOfTypesAndQty)
+	}
+}
+
+func TestNotificationCritical(t *testing.T) {
+	thresholds, err := NewThresholds([]Threshold{
+		{Before: 7 * 24 * time.Hour, Interval: 24 * time.Hour},
+		{Before: 6 * time.Hour, Interval: time.Hour, Critical: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	structure := func(id int64, expires time.Duration) structureData {
+		return structureData{
+			CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{
+				StructureId: id,
+				FuelExpires: time.Now().Add(expires),
+			},
+		}
+	}
+	b := &fuelBot{
+		sde:        &sde.Data{},
+		cfg:        Config{Thresholds: thresholds},
+		structures: []structureData{structure(1, 2*time.Hour), structure(2, 3*24*time.Hour)},
+	}
+
+	tests := []struct {
+		notificationType string
+		structureID      int64
+		critical         bool
+	}{
+		{notificationType: "StructureUnderAttack", critical: true},
+		{notificationType: "StructureWentLowPower", critical: true},
+		{notificationType: "StructureAnchoring", critical: false},
+		{notificationType: "StructureWentHighPower", critical: false},
+		{notificationType: "StructureFuelAlert", structureID: 1, critical: true},
+		{notificationType: "StructureFuelAlert", structureID: 2, critical: false},
+		{notificationType: "StructureFuelAlert", structureID: 3, critical: false},
+	}
+	for _, test := range tests {
+		critical := b.notificationCritical(test.notificationType, notificationBody{StructureID: test.structureID})
+		if critical != test.critical {
+			t.Errorf("notificationCritical(%s, %d) = %t, expected %t", test.notificationType, test.structureID, critical, test.critical)
+		}
+	}
+}
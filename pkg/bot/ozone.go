@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	liquidOzoneTypeID = 16273 // "Liquid Ozone"
+
+	// jumpGateGroup is structure group of Ansiblex jump gates, which
+	// use liquid ozone for every jump.
+	jumpGateGroup = "Upwell Jump Gate"
+)
+
+// OzoneConfig configures low liquid ozone warnings of jump gates.
+type OzoneConfig struct {
+	// Minimum liquid ozone in the fuel bay, 0 disables warnings.
+	Minimum int64
+	// Interval is how often the warning is repeated.
+	Interval time.Duration
+}
+
+// ozone returns liquid ozone in jump gate fuel bay, false when the
+// structure is not a jump gate or its fuel bay contents are unknown.
+func ozone(structure structureData, structureType sde.Structure) (int64, bool) {
+	if structureType.Group != jumpGateGroup || structure.FuelBay == nil {
+		return 0, false
+	}
+	return structure.FuelBay[liquidOzoneTypeID], true
+}
+
+// checkOzone sends notification for jump gates with less than minimum
+// liquid ozone, independently of fuel block notifications.
+func (b *fuelBot) checkOzone(structs []structureData) {
+	if b.cfg.Ozone.Minimum == 0 {
+		return
+	}
+	for _, structure := range structs {
+		id := structure.CorporationData.StructureId
+		quantity, ok := ozone(structure, b.structureByTypeID(structure.CorporationData.TypeId))
+		if !ok {
+			continue
+		}
+
+		b.stateMu.Lock()
+		notification, notified := b.state.OzoneNotified[id]
+		if quantity >= b.cfg.Ozone.Minimum && notified {
+			// Refilled, next time it runs low notify right away.
+			delete(b.state.OzoneNotified, id)
+			b.saveState()
+		}
+		b.stateMu.Unlock()
+		if quantity >= b.cfg.Ozone.Minimum {
+			continue
+		}
+		if notified && time.Since(notification.At) < b.cfg.Ozone.Interval {
+			continue
+		}
+		// Claimed, snoozed or ignored alerts are not repeated.
+		if _, acked := b.acked(id); acked {
+			continue
+		}
+
+		b.log.Infow("Sending ozone message",
+			"structure_id", id,
+			"structure_name", structure.UniverseData.Name,
+			"corporation", structure.Corporation.Name,
+			"ozone", quantity,
+		)
+		// Ozone has no thresholds, alert is sent without mention.
+		err := b.sendAlert(b.structureTarget(structure), Threshold{}, b.ozoneMessage(structure, quantity))
+		if err != nil {
+			continue
+		}
+
+		b.stateMu.Lock()
+		b.state.OzoneNotified[id] = state.Notification{At: time.Now()}
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+func (b *fuelBot) ozoneMessage(structure structureData, quantity int64) *discordgo.MessageEmbed {
+	return newEmbed("Jump gate running out of liquid ozone, FEED IT!", 0x00bfff, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: fmt.Sprintf("`%s`", structure.UniverseData.Name),
+		},
+		{
+			Name:  "How much?!",
+			Value: fmt.Sprintf("`%s` liquid ozone left", humanize.Comma(quantity)),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", structure.Corporation.Name, structure.Corporation.Ticker),
+		},
+	})
+}
+
+// formatOzone returns liquid ozone in jump gate fuel bay.
+func (b *fuelBot) formatOzone(structure structureData, structureType sde.Structure) string {
+	quantity, ok := ozone(structure, structureType)
+	if !ok {
+		return ""
+	}
+	msg := fmt.Sprintf(" \n **Liquid ozone**: %s", humanize.Comma(quantity))
+	if quantity < b.cfg.Ozone.Minimum {
+		msg += " :warning: low"
+	}
+	return msg
+}
@@ -0,0 +1,283 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/token"
+
+	"github.com/antihax/goesi"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// fuelBlockVolume is volume of one fuel block in m3.
+const fuelBlockVolume = 5
+
+// defaultPlanDays is how many days of fuel the plan tops structures up
+// to when not specified.
+const defaultPlanDays = 30
+
+var fuelBlockNames = map[int32]string{
+	heliumFuelBlockTypeID:   "Helium Fuel Block",
+	hydrogenFuelBlockTypeID: "Hydrogen Fuel Block",
+	nitrogenFuelBlockTypeID: "Nitrogen Fuel Block",
+	oxygenFuelBlockTypeID:   "Oxygen Fuel Block",
+}
+
+// Planner computes how much fuel is needed to top up structures.
+type Planner interface {
+	Plan(days int) (Plan, error)
+}
+
+// Plan of fuel needed to top up all structures to Days of fuel.
+type Plan struct {
+	Days   int
+	Items  []PlanItem
+	Prices price.Snapshot
+}
+
+// PlanItem is fuel needed to top up single structure.
+type PlanItem struct {
+	Name        string
+	SolarSystem string
+	Corporation string
+	Remaining   time.Duration
+	FuelPerDay  float64
+	// TypeID of fuel block to buy, the one already in the fuel bay or
+	// the cheapest one.
+	TypeID int32
+	Blocks int64
+	// Capped is true when Blocks were limited by fuel bay capacity.
+	Capped bool
+}
+
+// Blocks returns total number of fuel blocks needed.
+func (p Plan) Blocks() int64 {
+	var out int64
+	for _, item := range p.Items {
+		out += item.Blocks
+	}
+	return out
+}
+
+// Volume returns total volume of fuel blocks needed in m3.
+func (p Plan) Volume() float64 {
+	return float64(p.Blocks() * fuelBlockVolume)
+}
+
+// Cost returns total price of fuel blocks needed, 0 when prices
+// are not known.
+func (p Plan) Cost() float64 {
+	var out float64
+	for _, item := range p.Items {
+		if price, ok := p.Prices.Prices[item.TypeID]; ok {
+			out += float64(item.Blocks) * price
+		}
+	}
+	return out
+}
+
+// Multibuy returns fuel blocks needed in EVE multibuy format.
+func (p Plan) Multibuy() string {
+	blocks := make(map[int32]int64)
+	for _, item := range p.Items {
+		blocks[item.TypeID] += item.Blocks
+	}
+	var lines []string
+	for typeID, quantity := range blocks {
+		if quantity == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %d", fuelBlockNames[typeID], quantity))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// String returns plan as plain text.
+func (p Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Top up to %d days of fuel\n\n", p.Days)
+	for _, item := range p.Items {
+		fmt.Fprintf(&b, "%s (%s) [%s]: %s blocks, %s remaining, %.0f per day",
+			item.Name,
+			item.SolarSystem,
+			item.Corporation,
+			humanize.Comma(item.Blocks),
+			formatDuration(item.Remaining),
+			item.FuelPerDay,
+		)
+		if item.Capped {
+			b.WriteString(", capped by fuel bay")
+		}
+		b.WriteRune('\n')
+	}
+	fmt.Fprintf(&b, "\nTotal: %s blocks, %s m3, %s ISK\n\n%s\n",
+		humanize.Comma(p.Blocks()),
+		humanize.CommafWithDigits(p.Volume(), 0),
+		humanize.CommafWithDigits(p.Cost(), 0),
+		p.Multibuy(),
+	)
+	return b.String()
+}
+
+// NewPlanner returns planner reading structures of corporations of
+// tokenSources, it does not need Discord.
+func NewPlanner(log logger, client *http.Client, tokenSources []token.Source, prices *price.Cache, sdeData *sde.Data) Planner {
+	return &fuelBot{
+		tokenSources: tokenSources,
+		log:          log,
+		esi:          goesi.NewAPIClient(client, "EVE FuelBot"),
+		prices:       prices,
+		sde:          sdeData,
+		systems:      make(map[int32]solarSystem),
+		locations:    make(map[int64]location),
+		locationErrs: make(map[int64]time.Time),
+		assets:       make(map[int32][]asset),
+	}
+}
+
+// Plan loads structures and returns fuel needed to top them up to days.
+func (b *fuelBot) Plan(days int) (Plan, error) {
+	if days < 1 {
+		return Plan{}, errors.Errorf("days must be positive, got: %d", days)
+	}
+	structs, err := b.loadStructures()
+	if err != nil {
+		return Plan{}, err
+	}
+	return b.plan(structs, days, b.prices.Snapshot()), nil
+}
+
+func (b *fuelBot) plan(structs []structureData, days int, prices price.Snapshot) Plan {
+	out := Plan{
+		Days:   days,
+		Prices: prices,
+	}
+	for _, structure := range structs {
+		structureType := b.structureByTypeID(structure.CorporationData.TypeId)
+		fuelPerDay := b.structureFuelPerDay(structure, structureType)
+		if fuelPerDay == 0 {
+			continue
+		}
+
+		var remaining time.Duration
+		if !structure.CorporationData.FuelExpires.IsZero() {
+			remaining = time.Until(structure.CorporationData.FuelExpires)
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		needed := int64(math.Ceil((float64(days) - remaining.Hours()/24) * fuelPerDay))
+		if needed <= 0 {
+			continue
+		}
+
+		item := PlanItem{
+			Name:        structure.UniverseData.Name,
+			SolarSystem: structure.SolarSystem.Name,
+			Corporation: structure.Corporation.Ticker,
+			Remaining:   remaining,
+			FuelPerDay:  fuelPerDay,
+			TypeID:      planFuelType(structure, prices),
+			Blocks:      needed,
+		}
+		if structureType.FuelBay > 0 {
+			// Without fuel bay contents, estimate them from remaining time.
+			current := int64(remaining.Hours() / 24 * fuelPerDay)
+			if structure.FuelBay != nil {
+				current = fuelBayBlocks(structure)
+			}
+			free := int64(structureType.FuelBay/fuelBlockVolume) - current
+			if free < 0 {
+				free = 0
+			}
+			if needed > free {
+				item.Blocks = free
+				item.Capped = true
+			}
+		}
+		if item.Blocks == 0 {
+			continue
+		}
+		out.Items = append(out.Items, item)
+	}
+	sort.Slice(out.Items, func(i, j int) bool {
+		return out.Items[i].Remaining < out.Items[j].Remaining
+	})
+	return out
+}
+
+// planFuelType returns fuel block type most abundant in structure fuel
+// bay, or the cheapest one when fuel bay is empty or unknown.
+func planFuelType(structure structureData, prices price.Snapshot) int32 {
+	var (
+		out  int32
+		most int64
+	)
+	for _, typeID := range fuelBlockTypeIDs {
+		if structure.FuelBay[typeID] > most {
+			out, most = typeID, structure.FuelBay[typeID]
+		}
+	}
+	if out != 0 {
+		return out
+	}
+
+	var cheapest float64
+	for _, typeID := range fuelBlockTypeIDs {
+		price, ok := prices.Prices[typeID]
+		if ok && (out == 0 || price < cheapest) {
+			out, cheapest = typeID, price
+		}
+	}
+	if out == 0 {
+		// No prices known.
+		return fuelBlockTypeIDs[0]
+	}
+	return out
+}
+
+func (b *fuelBot) planMessage(plan Plan) []*discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, item := range plan.Items {
+		field := &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("%s (%s) [%s]", item.Name, item.SolarSystem, item.Corporation),
+			Value: fmt.Sprintf("**%s** %s \n**Remaining**: %s \n**Fuel per day**: %.0f",
+				humanize.Comma(item.Blocks),
+				fuelBlockNames[item.TypeID],
+				formatDuration(item.Remaining),
+				item.FuelPerDay,
+			),
+		}
+		if item.Capped {
+			field.Value += " \n:warning: capped by fuel bay capacity"
+		}
+		fields = append(fields, field)
+	}
+
+	total := "Nothing to buy, all structures have enough fuel."
+	if len(plan.Items) > 0 {
+		total = fmt.Sprintf("**Blocks**: %s \n**Volume**: %s m3 \n**Cost**: %s ISK \n```\n%s\n```%s",
+			humanize.Comma(plan.Blocks()),
+			humanize.CommafWithDigits(plan.Volume(), 0),
+			humanize.CommafWithDigits(plan.Cost(), 0),
+			plan.Multibuy(),
+			formatPricesAge(plan.Prices),
+		)
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:  ":shopping_cart: Shopping list",
+		Value: total,
+	})
+
+	return splitEmbed(newEmbed(fmt.Sprintf("Refuel plan for %d days", plan.Days), 0x00ff00, fields))
+}
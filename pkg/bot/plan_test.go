@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/price"
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+
+	"github.com/antihax/goesi/esi"
+)
+
+func TestPlan(t *testing.T) {
+	b := &fuelBot{
+		sde: &sde.Data{
+			Structures: map[int32]sde.Structure{
+				35832: {Name: "Astrahus", FuelBay: 1000},
+			},
+			Services: map[string][]sde.Service{
+				"citadel": {{Name: "Clone Bay", FuelPerHour: 10}},
+			},
+		},
+	}
+	structure := func(name string, expires time.Duration, bay map[int32]int64) structureData {
+		return structureData{
+			CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{
+				TypeId:      35832,
+				FuelExpires: time.Now().Add(expires),
+				Services: []esi.GetCorporationsCorporationIdStructuresService{
+					{Name: "Clone Bay", State: serviceStateOnline},
+				},
+			},
+			UniverseData: esi.GetUniverseStructuresStructureIdOk{Name: name},
+			FuelBay:      bay,
+		}
+	}
+	structs := []structureData{
+		// 240 blocks per day, fuel bay holds 200 blocks and is full.
+		structure("full", 36*time.Hour, map[int32]int64{oxygenFuelBlockTypeID: 200}),
+		// Needs 240 blocks, only 100 fit.
+		structure("capped", 24*time.Hour+time.Minute, map[int32]int64{nitrogenFuelBlockTypeID: 100}),
+		structure("enough", 60*24*time.Hour, nil),
+	}
+	prices := price.Snapshot{
+		Prices: map[int32]float64{
+			heliumFuelBlockTypeID:   30,
+			hydrogenFuelBlockTypeID: 20,
+			nitrogenFuelBlockTypeID: 10,
+			oxygenFuelBlockTypeID:   40,
+		},
+	}
+
+	plan := b.plan(structs, 2, prices)
+	if len(plan.Items) != 1 {
+		t.Fatalf("expected 1 structure to refuel, got: %+v", plan.Items)
+	}
+	item := plan.Items[0]
+	if item.Name != "capped" || item.Blocks != 100 || !item.Capped || item.TypeID != nitrogenFuelBlockTypeID {
+		t.Errorf("unexpected plan item: %+v", item)
+	}
+	if plan.Volume() != 500 || plan.Cost() != 1000 {
+		t.Errorf("unexpected volume %.0f or cost %.0f", plan.Volume(), plan.Cost())
+	}
+	if plan.Multibuy() != "Nitrogen Fuel Block 100" {
+		t.Errorf("unexpected multibuy: %q", plan.Multibuy())
+	}
+}
+
+func TestPlanFuelTypeMissingPrice(t *testing.T) {
+	prices := price.Snapshot{
+		Prices: map[int32]float64{
+			nitrogenFuelBlockTypeID: 20,
+			oxygenFuelBlockTypeID:   10,
+		},
+	}
+	if typeID := planFuelType(structureData{}, prices); typeID != oxygenFuelBlockTypeID {
+		t.Errorf("expected the cheapest priced type, got: %d", typeID)
+	}
+}
@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// checkRefuels compares each structure fuel expiration and each starbase
+// fuel blocks with the previous check. When fuel increased by more than
+// refuelDetection, confirmation is sent and its fuel notification is reset.
+func (b *fuelBot) checkRefuels(structs []structureData, starbases []starbaseData) {
+	for _, structure := range structs {
+		b.checkStructureRefuel(structure)
+	}
+	for _, starbase := range starbases {
+		b.checkStarbaseRefuel(starbase)
+	}
+}
+
+func (b *fuelBot) checkStructureRefuel(structure structureData) {
+	t := b.structureTarget(structure)
+	expires := structure.CorporationData.FuelExpires
+	blocks := fuelBayBlocks(structure)
+
+	b.stateMu.Lock()
+	previous, known := b.state.FuelExpires[t.ID]
+	previousBlocks, knownBlocks := b.state.FuelBlocks[t.ID]
+	b.stateMu.Unlock()
+
+	added, refuelled := b.refuelled(previous, expires)
+	if refuelled && structure.FuelBay != nil && knownBlocks && blocks <= previousBlocks {
+		// Fuel expiration also moves forward when a service goes offline,
+		// refuel is confirmed by fuel blocks added when they are known.
+		refuelled = false
+	}
+	if known && refuelled && !b.refuel(t, added, b.refuelMessage(&structure, added)) {
+		// Fuel expiration is not updated, so it is picked up on next iteration.
+		return
+	}
+
+	b.stateMu.Lock()
+	// Last fuel expiration is kept when structure runs out of fuel, it
+	// tells when low power started.
+	if !expires.IsZero() || !known {
+		b.state.FuelExpires[t.ID] = expires
+	}
+	if structure.FuelBay != nil {
+		b.state.FuelBlocks[t.ID] = blocks
+	}
+	b.saveState()
+	b.stateMu.Unlock()
+}
+
+// checkStarbaseRefuel compares fuel blocks in starbase, its fuel expiration
+// is computed from now on every check and moves forward even when nothing
+// was added.
+func (b *fuelBot) checkStarbaseRefuel(starbase starbaseData) {
+	if starbase.Tower.FuelPerHour == 0 {
+		return
+	}
+	t := starbaseTarget(starbase)
+	blocks := starbase.Fuels[starbase.Tower.FuelTypeID]
+
+	b.stateMu.Lock()
+	previous, known := b.state.FuelBlocks[t.ID]
+	b.stateMu.Unlock()
+
+	added := time.Duration(float64(blocks-previous) / float64(starbase.Tower.FuelPerHour) * float64(time.Hour))
+	if known && added > b.cfg.RefuelDetection && !b.refuel(t, added, b.starbaseRefuelMessage(starbase, added)) {
+		return
+	}
+
+	b.stateMu.Lock()
+	b.state.FuelBlocks[t.ID] = blocks
+	b.saveState()
+	b.stateMu.Unlock()
+}
+
+// refuel sends refuel message of structure or starbase and resets its
+// notifications, it returns false when the message was not sent.
+func (b *fuelBot) refuel(t target, added time.Duration, message *discordgo.MessageEmbed) bool {
+	b.log.Infow("Sending refuel message",
+		"id", t.ID,
+		"name", t.Name,
+		"added", added,
+	)
+	err := b.send(t, "", message)
+	if err != nil {
+		return false
+	}
+
+	b.stateMu.Lock()
+	// Refuelled structure starts notifications from scratch, fuel bay
+	// of Metenox or jump gate is usually topped up with gas or ozone
+	// at the same time.
+	delete(b.state.Notified, t.ID)
+	delete(b.state.GasNotified, t.ID)
+	delete(b.state.OzoneNotified, t.ID)
+	delete(b.state.Escalations, t.ID)
+	if b.state.Acks[t.ID].Action != ackIgnore {
+		delete(b.state.Acks, t.ID)
+	}
+	b.saveState()
+	b.stateMu.Unlock()
+	b.dropDeferred(t.ID)
+	return true
+}
+
+// dropDeferred drops alerts about structure or starbase with id held
+// back by quiet hours, they are stale after refuel.
+func (b *fuelBot) dropDeferred(id int64) {
+	if b.cfg.Deferred == nil {
+		return
+	}
+	err := b.cfg.Deferred.Drop(id)
+	if err != nil {
+		b.log.Errorw("Error dropping deferred alerts",
+			"id", id,
+			"error", err,
+		)
+	}
+}
+
+// refuelled returns how much fuel was added between previous and current
+// fuel expiration, and whether it is enough to count as refuel.
+func (b *fuelBot) refuelled(previous, current time.Time) (time.Duration, bool) {
+	if current.IsZero() {
+		return 0, false
+	}
+	// Structure without fuel is refuelled from now.
+	from := previous
+	if from.IsZero() || from.Before(time.Now()) {
+		from = time.Now()
+	}
+	added := current.Sub(from)
+	return added, added > b.cfg.RefuelDetection
+}
+
+func (b *fuelBot) refuelMessage(structure *structureData, added time.Duration) *discordgo.MessageEmbed {
+	expires := structure.CorporationData.FuelExpires
+	return newEmbed("Citadel refuelled, om nom nom!", 0x00ff00, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?",
+			Value: fmt.Sprintf("`%s`", structure.UniverseData.Name),
+		},
+		{
+			Name:  "Whose?",
+			Value: fmt.Sprintf("`%s` [%s]", structure.Corporation.Name, structure.Corporation.Ticker),
+		},
+		{
+			Name: "Fuel",
+			Value: fmt.Sprintf("`+%s`, now expires %s (%s)",
+				formatDuration(added),
+				formatTime(expires),
+				expires,
+			),
+		},
+	})
+}
+
+// formatDuration formats duration in days and hours, eg. "23 days 4 hours".
+func formatDuration(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, plural(days, "day"))
+	}
+	if hours > 0 || days == 0 {
+		parts = append(parts, plural(hours, "hour"))
+	}
+	return strings.Join(parts, " ")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"go.uber.org/zap"
+)
+
+func TestStructureRefuel(t *testing.T) {
+	notifier := &recordNotifier{}
+	b := &fuelBot{
+		log:          zap.NewNop().Sugar(),
+		sde:          &sde.Data{},
+		notifier:     notifier,
+		cfg:          Config{RefuelDetection: time.Hour},
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	structure := structureData{
+		CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 1, FuelExpires: time.Now().Add(24 * time.Hour)},
+		FuelBay:         map[int32]int64{heliumFuelBlockTypeID: 240},
+	}
+	b.checkRefuels([]structureData{structure}, nil)
+
+	// Service went offline, the same fuel lasts longer.
+	structure.CorporationData.FuelExpires = time.Now().Add(3 * 24 * time.Hour)
+	b.checkRefuels([]structureData{structure}, nil)
+	if len(notifier.msgs) != 0 {
+		t.Fatalf("service going offline should not be refuel: %+v", notifier.msgs)
+	}
+
+	structure.CorporationData.FuelExpires = time.Now().Add(10 * 24 * time.Hour)
+	structure.FuelBay[heliumFuelBlockTypeID] = 800
+	b.checkRefuels([]structureData{structure}, nil)
+	if len(notifier.msgs) != 1 || notifier.msgs[0].TargetID != 1 {
+		t.Fatalf("expected refuel message, got: %+v", notifier.msgs)
+	}
+
+	// Without fuel bay contents, expiration moving forward is refuel.
+	structure.FuelBay = nil
+	structure.CorporationData.FuelExpires = time.Now().Add(20 * 24 * time.Hour)
+	b.checkRefuels([]structureData{structure}, nil)
+	if len(notifier.msgs) != 2 {
+		t.Fatalf("expected refuel message without fuel bay contents, got: %+v", notifier.msgs)
+	}
+}
@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+
+	"github.com/pkg/errors"
+)
+
+// RouteConfig sends alerts of structures matching Match to Notifiers,
+// with own Thresholds when set.
+type RouteConfig struct {
+	Name       string      `mapstructure:"name"`
+	Match      Match       `mapstructure:"match"`
+	Notifiers  []string    `mapstructure:"notifiers"`
+	Thresholds []Threshold `mapstructure:"thresholds"`
+}
+
+// Match selects structures, all non-empty criteria have to match, any
+// value of each criteria matches. Names are case insensitive regular
+// expressions, other values are compared case insensitive.
+type Match struct {
+	IDs          []int64  `mapstructure:"ids"`
+	Names        []string `mapstructure:"names"`
+	Types        []string `mapstructure:"types"`
+	Systems      []string `mapstructure:"systems"`
+	Regions      []string `mapstructure:"regions"`
+	Corporations []string `mapstructure:"corporations"`
+}
+
+// Route is validated RouteConfig.
+type Route struct {
+	name       string
+	match      Match
+	names      []*regexp.Regexp
+	notifier   notify.Notifier
+	thresholds Thresholds
+}
+
+// NewRoutes validates routes and resolves their notifiers by name.
+func NewRoutes(configs []RouteConfig, notifiers map[string]notify.Notifier) ([]Route, error) {
+	var out []Route
+	for i, cfg := range configs {
+		route := Route{
+			name:  cfg.Name,
+			match: cfg.Match,
+		}
+		if route.name == "" {
+			route.name = strings.Join(cfg.Notifiers, ",")
+		}
+		for _, name := range cfg.Match.Names {
+			re, err := regexp.Compile("(?i)" + name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "route %d: invalid name pattern", i)
+			}
+			route.names = append(route.names, re)
+		}
+
+		notifier, err := namedNotifier(cfg.Notifiers, notifiers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "route %d", i)
+		}
+		route.notifier = notifier
+
+		if len(cfg.Thresholds) != 0 {
+			thresholds, err := NewThresholds(cfg.Thresholds)
+			if err != nil {
+				return nil, errors.Wrapf(err, "route %d", i)
+			}
+			route.thresholds = thresholds
+		}
+		out = append(out, route)
+	}
+	return out, nil
+}
+
+// namedNotifier returns notifier sending to all notifiers with names.
+func namedNotifier(names []string, notifiers map[string]notify.Notifier) (notify.Notifier, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no notifiers")
+	}
+	var out []notify.Notifier
+	for _, name := range names {
+		notifier, ok := notifiers[name]
+		if !ok {
+			return nil, errors.Errorf("unknown notifier: %s", name)
+		}
+		out = append(out, notifier)
+	}
+	return notify.NewMulti(out...), nil
+}
+
+// target is structure or starbase alert is about, zero target is
+// not matched by any route.
+type target struct {
+	ID          int64
+	Name        string
+	Type        string
+	SolarSystem solarSystem
+	Corporation corporation
+}
+
+func structureTarget(structure structureData, structureType string) target {
+	return target{
+		ID:          structure.CorporationData.StructureId,
+		Name:        structure.UniverseData.Name,
+		Type:        structureType,
+		SolarSystem: structure.SolarSystem,
+		Corporation: structure.Corporation,
+	}
+}
+
+func (b *fuelBot) structureTarget(structure structureData) target {
+	return structureTarget(structure, b.structureByTypeID(structure.CorporationData.TypeId).Name)
+}
+
+func starbaseTarget(starbase starbaseData) target {
+	return target{
+		ID:          starbase.CorporationData.StarbaseId,
+		Name:        starbase.Name(),
+		Type:        starbase.Tower.Name,
+		SolarSystem: starbase.SolarSystem,
+		Corporation: starbase.Corporation,
+	}
+}
+
+// loadedTarget returns target of loaded structure or starbase with id,
+// zero target when it is not loaded.
+func (b *fuelBot) loadedTarget(id int64) target {
+	for _, structure := range b.loadedStructures() {
+		if structure.CorporationData.StructureId == id {
+			return b.structureTarget(structure)
+		}
+	}
+	for _, starbase := range b.loadedStarbases() {
+		if starbase.CorporationData.StarbaseId == id {
+			return starbaseTarget(starbase)
+		}
+	}
+	return target{}
+}
+
+func (r Route) matches(t target) bool {
+	if t.ID == 0 {
+		return false
+	}
+	if len(r.match.IDs) != 0 && !matchID(r.match.IDs, t.ID) {
+		return false
+	}
+	if len(r.names) != 0 && !matchName(r.names, t.Name) {
+		return false
+	}
+	if len(r.match.Types) != 0 && !matchString(r.match.Types, t.Type) {
+		return false
+	}
+	if len(r.match.Systems) != 0 && !matchString(r.match.Systems, t.SolarSystem.Name) {
+		return false
+	}
+	if len(r.match.Regions) != 0 && !matchString(r.match.Regions, t.SolarSystem.RegionName) {
+		return false
+	}
+	if len(r.match.Corporations) != 0 && !matchString(r.match.Corporations, t.Corporation.Name) && !matchString(r.match.Corporations, t.Corporation.Ticker) {
+		return false
+	}
+	return true
+}
+
+func matchID(ids []int64, id int64) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func matchName(names []*regexp.Regexp, name string) bool {
+	for _, re := range names {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchString(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the first route matching target, false when alert
+// should go to the default notifier.
+func (b *fuelBot) route(t target) (Route, bool) {
+	for _, route := range b.cfg.Routes {
+		if route.matches(t) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// thresholds returns thresholds of the route matching target.
+func (b *fuelBot) thresholds(t target) Thresholds {
+	route, ok := b.route(t)
+	if ok && route.thresholds != nil {
+		return route.thresholds
+	}
+	return b.cfg.Thresholds
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+)
+
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(notify.Message) error { return nil }
+
+// recordNotifier remembers all messages sent.
+type recordNotifier struct {
+	msgs []notify.Message
+}
+
+func (r *recordNotifier) Notify(msg notify.Message) error {
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func TestRouteMatches(t *testing.T) {
+	routes, err := NewRoutes([]RouteConfig{
+		{Match: Match{Types: []string{"ansiblex jump gate"}}, Notifiers: []string{"sov"}},
+		{Match: Match{Names: []string{"market|trade hub"}, Regions: []string{"Delve"}}, Notifiers: []string{"indy"}},
+		{Match: Match{Corporations: []string{"TEST"}, IDs: []int64{1, 2}}, Notifiers: []string{"sov", "indy"}},
+	}, map[string]notify.Notifier{"sov": nopNotifier{}, "indy": nopNotifier{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delve := solarSystem{Name: "1DQ1-A", RegionName: "Delve"}
+	tests := []struct {
+		target target
+		route  int
+	}{
+		{target: target{ID: 3, Type: "Ansiblex Jump Gate"}, route: 0},
+		{target: target{ID: 3, Name: "1DQ1-A - Market", SolarSystem: delve}, route: 1},
+		{target: target{ID: 3, Name: "1DQ1-A - Market"}, route: -1},
+		{target: target{ID: 2, Corporation: corporation{Ticker: "test"}}, route: 2},
+		{target: target{ID: 3, Corporation: corporation{Ticker: "TEST"}}, route: -1},
+		{target: target{}, route: -1},
+	}
+	for i, test := range tests {
+		route := -1
+		for j := range routes {
+			if routes[j].matches(test.target) {
+				route = j
+				break
+			}
+		}
+		if route != test.route {
+			t.Errorf("%d: matched route %d, expected %d", i, route, test.route)
+		}
+	}
+}
+
+func TestNewRoutesUnknownNotifier(t *testing.T) {
+	_, err := NewRoutes([]RouteConfig{{Notifiers: []string{"missing"}}}, nil)
+	if err == nil {
+		t.Error("expected error for unknown notifier")
+	}
+}
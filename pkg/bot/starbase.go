@@ -0,0 +1,325 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+const (
+	strontiumTypeID = 16275 // "Strontium Clathrates"
+
+	starbaseStateOffline    = "offline"
+	starbaseStateOnline     = "online"
+	starbaseStateOnlining   = "onlining"
+	starbaseStateReinforced = "reinforced"
+)
+
+// StrontiumConfig configures low strontium warnings of starbases.
+type StrontiumConfig struct {
+	// Minimum reinforcement strontium has to last, 0 disables warnings.
+	Minimum time.Duration
+	// Interval is how often the warning is repeated.
+	Interval time.Duration
+}
+
+// starbaseData is POS control tower and its fuel.
+type starbaseData struct {
+	Corporation     corporation
+	SolarSystem     solarSystem
+	Moon            string
+	CorporationData esi.GetCorporationsCorporationIdStarbases200Ok
+	// Fuels holds quantity of each item type in the tower fuel bay.
+	Fuels map[int32]int64
+	// Tower is zero when tower type is unknown.
+	Tower sde.Tower
+}
+
+// Name returns moon the tower is anchored at, or its solar system
+// when it is not anchored.
+func (s starbaseData) Name() string {
+	if s.Moon != "" {
+		return s.Moon
+	}
+	return s.SolarSystem.Name
+}
+
+// burnsFuel checks if the tower burns fuel blocks, only online towers
+// do. Reinforced tower burns strontium instead.
+func (s starbaseData) burnsFuel() bool {
+	switch s.CorporationData.State {
+	case starbaseStateOnline, starbaseStateOnlining:
+		return true
+	}
+	return false
+}
+
+// FuelExpires returns when the tower runs out of fuel blocks, zero time
+// when it does not burn fuel.
+func (s starbaseData) FuelExpires() time.Time {
+	if s.Tower.FuelPerHour == 0 || !s.burnsFuel() {
+		return time.Time{}
+	}
+	hours := float64(s.Fuels[s.Tower.FuelTypeID]) / float64(s.Tower.FuelPerHour)
+	return time.Now().Add(time.Duration(hours * float64(time.Hour)))
+}
+
+// FuelPerDay returns fuel blocks the tower burns per day.
+func (s starbaseData) FuelPerDay() float64 {
+	if !s.burnsFuel() {
+		return 0
+	}
+	return float64(s.Tower.FuelPerHour) * 24
+}
+
+// Reinforcement returns how long strontium keeps the tower reinforced.
+func (s starbaseData) Reinforcement() time.Duration {
+	if s.Tower.StrontiumPerHour == 0 {
+		return 0
+	}
+	hours := s.Fuels[strontiumTypeID] / int64(s.Tower.StrontiumPerHour)
+	return time.Duration(hours) * time.Hour
+}
+
+// loadCorporationStarbases loads POS control towers, this needs
+// esi-corporations.read_starbases.v1 scope and Director role.
+func (b *fuelBot) loadCorporationStarbases(corp corporation) ([]starbaseData, error) {
+	ctx := corp.ctx()
+	starbases, _, err := b.esi.ESI.CorporationApi.GetCorporationsCorporationIdStarbases(ctx, corp.ID, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read corporation starbases")
+	}
+
+	var out []starbaseData
+	for _, starbase := range starbases {
+		detail, _, err := b.esi.ESI.CorporationApi.GetCorporationsCorporationIdStarbasesStarbaseId(ctx, corp.ID, starbase.StarbaseId, starbase.SystemId, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load starbase info for starbase: %d", starbase.StarbaseId)
+		}
+		system, err := b.solarSystem(ctx, starbase.SystemId)
+		if err != nil {
+			return nil, err
+		}
+		var moon string
+		if starbase.MoonId != 0 {
+			loc, err := b.location(ctx, int64(starbase.MoonId))
+			if err != nil {
+				// Log but keep the starbase, it burns fuel anyway.
+				b.log.Errorw("Error loading moon name",
+					"moon_id", starbase.MoonId,
+					"error", err,
+				)
+				loc.Name = fmt.Sprintf("moon ID: %d", starbase.MoonId)
+			}
+			moon = loc.Name
+		}
+		fuels := make(map[int32]int64)
+		for _, fuel := range detail.Fuels {
+			fuels[fuel.TypeId] += int64(fuel.Quantity)
+		}
+		out = append(out, starbaseData{
+			Corporation:     corp,
+			SolarSystem:     system,
+			Moon:            moon,
+			CorporationData: starbase,
+			Fuels:           fuels,
+			Tower:           b.towerByTypeID(starbase.TypeId),
+		})
+	}
+	return out, nil
+}
+
+// loadedStarbases returns starbases from the last successful
+// loadStructures call.
+func (b *fuelBot) loadedStarbases() []starbaseData {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+	return b.starbases
+}
+
+func (b *fuelBot) towerByTypeID(typeID int32) sde.Tower {
+	tower, ok := b.sde.Towers[typeID]
+	if !ok {
+		return sde.Tower{
+			Name: fmt.Sprintf("unknown control tower type ID: %d", typeID),
+		}
+	}
+	return tower
+}
+
+// checkStarbaseFuel sends notification for starbases running out of fuel.
+func (b *fuelBot) checkStarbaseFuel(starbases []starbaseData) {
+	for _, starbase := range starbases {
+		target := starbaseTarget(starbase)
+		threshold, notify := b.shouldNotify(resourceFuel, target, starbase.FuelExpires())
+		if !notify {
+			continue
+		}
+		b.log.Infow("Sending starbase message",
+			"starbase_id", starbase.CorporationData.StarbaseId,
+			"starbase_name", starbase.Name(),
+			"corporation", starbase.Corporation.Name,
+			"threshold", threshold.Before,
+		)
+		err := b.sendAlert(target, threshold, b.starbaseMessage(starbase, threshold))
+		if err != nil {
+			continue
+		}
+		b.setWasNotified(resourceFuel, starbase.CorporationData.StarbaseId, threshold)
+	}
+}
+
+// checkStrontium sends notification for online starbases whose
+// strontium would not last the minimum reinforcement.
+func (b *fuelBot) checkStrontium(starbases []starbaseData) {
+	if b.cfg.Strontium.Minimum == 0 {
+		return
+	}
+	for _, starbase := range starbases {
+		if starbase.Tower.StrontiumPerHour == 0 || starbase.CorporationData.State != starbaseStateOnline {
+			continue
+		}
+		id := starbase.CorporationData.StarbaseId
+		reinforcement := starbase.Reinforcement()
+
+		b.stateMu.Lock()
+		notification, notified := b.state.StrontiumNotified[id]
+		if reinforcement >= b.cfg.Strontium.Minimum && notified {
+			// Refilled, next time it runs low notify right away.
+			delete(b.state.StrontiumNotified, id)
+			b.saveState()
+		}
+		b.stateMu.Unlock()
+		if reinforcement >= b.cfg.Strontium.Minimum {
+			continue
+		}
+		if notified && time.Since(notification.At) < b.cfg.Strontium.Interval {
+			continue
+		}
+		// Claimed, snoozed or ignored alerts are not repeated.
+		if _, acked := b.acked(id); acked {
+			continue
+		}
+
+		b.log.Infow("Sending strontium message",
+			"starbase_id", id,
+			"starbase_name", starbase.Name(),
+			"corporation", starbase.Corporation.Name,
+			"reinforcement", reinforcement,
+		)
+		// Strontium has no thresholds, alert is sent without mention.
+		err := b.sendAlert(starbaseTarget(starbase), Threshold{}, b.strontiumMessage(starbase))
+		if err != nil {
+			continue
+		}
+
+		b.stateMu.Lock()
+		b.state.StrontiumNotified[id] = state.Notification{At: time.Now()}
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+func (b *fuelBot) strontiumMessage(starbase starbaseData) *discordgo.MessageEmbed {
+	return newEmbed("POS running out of strontium, FEED IT!", 0x00bfff, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: fmt.Sprintf("`%s` (%s)", starbase.Name(), starbase.Tower.Name),
+		},
+		{
+			Name: "How much?!",
+			Value: fmt.Sprintf("`%s` strontium, reinforced for `%s`",
+				humanize.Comma(starbase.Fuels[strontiumTypeID]),
+				formatDuration(starbase.Reinforcement()),
+			),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", starbase.Corporation.Name, starbase.Corporation.Ticker),
+		},
+	})
+}
+
+func (b *fuelBot) starbaseRefuelMessage(starbase starbaseData, added time.Duration) *discordgo.MessageEmbed {
+	fuel := fmt.Sprintf("`+%s`", formatDuration(added))
+	// Offline or reinforced tower does not burn the fuel yet.
+	if expires := starbase.FuelExpires(); !expires.IsZero() {
+		fuel += fmt.Sprintf(", now expires %s (%s)", formatTime(expires), expires)
+	}
+	return newEmbed("POS refuelled, om nom nom!", 0x00ff00, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?",
+			Value: fmt.Sprintf("`%s` (%s)", starbase.Name(), starbase.Tower.Name),
+		},
+		{
+			Name:  "Whose?",
+			Value: fmt.Sprintf("`%s` [%s]", starbase.Corporation.Name, starbase.Corporation.Ticker),
+		},
+		{
+			Name:  "Fuel",
+			Value: fuel,
+		},
+	})
+}
+
+func (b *fuelBot) starbaseMessage(starbase starbaseData, threshold Threshold) *discordgo.MessageEmbed {
+	expires := starbase.FuelExpires()
+	return newEmbed("POS running out of fuel, FEED IT!", threshold.Color, []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: fmt.Sprintf("`%s` (%s)", starbase.Name(), starbase.Tower.Name),
+		},
+		{
+			Name:  "When?!",
+			Value: fmt.Sprintf("%s (%s)", formatTime(expires), expires),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", starbase.Corporation.Name, starbase.Corporation.Ticker),
+		},
+	})
+}
+
+// starbaseField returns POS field for "!fuel" message.
+func starbaseField(starbase starbaseData, showOwner bool) *discordgo.MessageEmbedField {
+	expires := starbase.FuelExpires()
+	field := &discordgo.MessageEmbedField{
+		Name: fmt.Sprintf("%s %s (%s)",
+			fuelSymbol(expires),
+			starbase.Name(),
+			starbase.Tower.Name,
+		),
+	}
+	if showOwner {
+		field.Name = fmt.Sprintf("%s [%s]", field.Name, starbase.Corporation.Ticker)
+	}
+	if starbase.CorporationData.State == starbaseStateReinforced {
+		until := starbase.CorporationData.ReinforcedUntil
+		field.Value = fmt.Sprintf("`%s` until `%s` (%s) \n **Fuel blocks**: %s",
+			starbase.CorporationData.State,
+			humanize.Time(until),
+			until,
+			humanize.Comma(starbase.Fuels[starbase.Tower.FuelTypeID]),
+		)
+		return field
+	}
+	if expires.IsZero() {
+		field.Value = fmt.Sprintf("`%s`", starbase.CorporationData.State)
+		return field
+	}
+	field.Value = fmt.Sprintf("`%s` (%s) \n **Fuel blocks**: %s \n **Fuel per day**: %.0f \n **Strontium**: %s",
+		humanize.Time(expires),
+		expires,
+		humanize.Comma(starbase.Fuels[starbase.Tower.FuelTypeID]),
+		starbase.FuelPerDay(),
+		formatDuration(starbase.Reinforcement()),
+	)
+	return field
+}
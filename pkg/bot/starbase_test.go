@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"go.uber.org/zap"
+)
+
+func TestStarbaseFuel(t *testing.T) {
+	starbase := starbaseData{
+		CorporationData: esi.GetCorporationsCorporationIdStarbases200Ok{State: "online"},
+		Fuels: map[int32]int64{
+			heliumFuelBlockTypeID: 960,
+			strontiumTypeID:       4100,
+		},
+		Tower: sde.Tower{FuelTypeID: heliumFuelBlockTypeID, FuelPerHour: 40, StrontiumPerHour: 400},
+	}
+
+	remaining := time.Until(starbase.FuelExpires())
+	if remaining < 23*time.Hour || remaining > 24*time.Hour {
+		t.Errorf("expected 24 hours of fuel, got: %s", remaining)
+	}
+	if starbase.Reinforcement() != 10*time.Hour {
+		t.Errorf("expected 10 hours of reinforcement, got: %s", starbase.Reinforcement())
+	}
+
+	starbase.CorporationData.State = starbaseStateOffline
+	if !starbase.FuelExpires().IsZero() || starbase.FuelPerDay() != 0 {
+		t.Errorf("offline starbase should not burn fuel")
+	}
+	starbase.CorporationData.State = starbaseStateReinforced
+	if !starbase.FuelExpires().IsZero() || starbase.FuelPerDay() != 0 {
+		t.Errorf("reinforced starbase should burn strontium, not fuel")
+	}
+}
+
+func TestStarbaseRefuel(t *testing.T) {
+	notifier := &recordNotifier{}
+	b := &fuelBot{
+		log:          zap.NewNop().Sugar(),
+		notifier:     notifier,
+		cfg:          Config{RefuelDetection: time.Hour},
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	starbase := starbaseData{
+		CorporationData: esi.GetCorporationsCorporationIdStarbases200Ok{StarbaseId: 1, State: "online"},
+		Fuels:           map[int32]int64{heliumFuelBlockTypeID: 40},
+		Tower:           sde.Tower{FuelTypeID: heliumFuelBlockTypeID, FuelPerHour: 40},
+	}
+
+	b.checkRefuels(nil, []starbaseData{starbase})
+	b.state.Notified[1] = state.Notification{At: time.Now()}
+
+	// Fuel expiration of the same blocks moves forward with every check.
+	b.checkRefuels(nil, []starbaseData{starbase})
+	if len(notifier.msgs) != 0 {
+		t.Fatalf("starbase with the same fuel blocks should not be refuelled: %+v", notifier.msgs)
+	}
+
+	starbase.CorporationData.State = starbaseStateReinforced
+	b.checkRefuels(nil, []starbaseData{starbase})
+	if len(notifier.msgs) != 0 {
+		t.Fatalf("reinforced starbase should not be refuelled: %+v", notifier.msgs)
+	}
+
+	starbase.CorporationData.State = starbaseStateOnline
+	starbase.Fuels[heliumFuelBlockTypeID] = 960
+	b.checkRefuels(nil, []starbaseData{starbase})
+	if len(notifier.msgs) != 1 || notifier.msgs[0].TargetID != 1 {
+		t.Fatalf("expected refuel message, got: %+v", notifier.msgs)
+	}
+	if _, ok := b.state.Notified[1]; ok {
+		t.Error("refuel should reset starbase notification")
+	}
+}
+
+func TestStrontiumAck(t *testing.T) {
+	notifier := &recordNotifier{}
+	b := &fuelBot{
+		log:          zap.NewNop().Sugar(),
+		notifier:     notifier,
+		cfg:          Config{Strontium: StrontiumConfig{Minimum: 24 * time.Hour, Interval: time.Hour}},
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	starbase := starbaseData{
+		CorporationData: esi.GetCorporationsCorporationIdStarbases200Ok{StarbaseId: 1, State: starbaseStateOnline},
+		Fuels:           map[int32]int64{strontiumTypeID: 400},
+		Tower:           sde.Tower{StrontiumPerHour: 400},
+	}
+
+	b.state.Acks[1] = state.Ack{Action: ackSnooze, Until: time.Now().Add(time.Hour)}
+	b.checkStrontium([]starbaseData{starbase})
+	if len(notifier.msgs) != 0 {
+		t.Fatalf("snoozed starbase should not be notified: %+v", notifier.msgs)
+	}
+
+	delete(b.state.Acks, 1)
+	b.checkStrontium([]starbaseData{starbase})
+	if len(notifier.msgs) != 1 || len(notifier.msgs[0].Components) == 0 {
+		t.Fatalf("expected strontium alert with ack buttons, got: %+v", notifier.msgs)
+	}
+}
@@ -0,0 +1,304 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// Stock coverage areas.
+const (
+	StockCoverageSystem = "system"
+	StockCoverageRegion = "region"
+)
+
+// locationFlagCorpHangar is prefix of corporation hangar division flags
+// "CorpSAG1" to "CorpSAG7".
+const locationFlagCorpHangar = "CorpSAG"
+
+// StockConfig configures corporation hangar fuel stock warnings.
+type StockConfig struct {
+	// Days of fuel the stock has to cover, 0 disables warnings.
+	Days int
+	// Coverage is whether stock covers structures in the same
+	// solar system or the same region.
+	Coverage string
+	// Interval is how often the warning is repeated.
+	Interval time.Duration
+}
+
+// hangarKey identifies corporation hangar division at location.
+type hangarKey struct {
+	LocationID int64
+	Division   int
+}
+
+// stockHangar is fuel stored in corporation hangar division.
+type stockHangar struct {
+	Location location
+	Division int
+	Blocks   int64
+}
+
+// stockArea is solar system or region with fuel stock and structures.
+type stockArea struct {
+	ID      int32
+	Name    string
+	Hangars []stockHangar
+	Blocks  int64
+	// Burn is daily fuel consumption of structures in the area.
+	Burn float64
+}
+
+// Days returns how many days the stock lasts.
+func (a stockArea) Days() float64 {
+	if a.Burn == 0 {
+		return 0
+	}
+	return float64(a.Blocks) / a.Burn
+}
+
+// hangarStock returns fuel blocks in each corporation hangar division,
+// including those in containers.
+func hangarStock(assets []asset) map[hangarKey]int64 {
+	byID := make(map[int64]asset)
+	for _, asset := range assets {
+		byID[asset.ItemId] = asset
+	}
+
+	out := make(map[hangarKey]int64)
+	for _, item := range assets {
+		if !isFuelBlock(item.TypeId) {
+			continue
+		}
+		var (
+			division int
+			current  = item
+		)
+		for {
+			if strings.HasPrefix(current.LocationFlag, locationFlagCorpHangar) {
+				division, _ = strconv.Atoi(strings.TrimPrefix(current.LocationFlag, locationFlagCorpHangar))
+			}
+			parent, ok := byID[current.LocationId]
+			if !ok {
+				break
+			}
+			current = parent
+		}
+		if division == 0 {
+			continue
+		}
+		out[hangarKey{LocationID: current.LocationId, Division: division}] += int64(item.Quantity)
+	}
+	return out
+}
+
+func isFuelBlock(typeID int32) bool {
+	for _, fuelBlockTypeID := range fuelBlockTypeIDs {
+		if typeID == fuelBlockTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// hangarLocation returns location of corporation hangar. Structures of
+// the corporation are loaded already, others are looked up.
+func (b *fuelBot) hangarLocation(corp corporation, structs []structureData, locationID int64) (location, error) {
+	for _, structure := range structs {
+		if structure.CorporationData.StructureId == locationID {
+			return location{
+				ID:          locationID,
+				Name:        structure.UniverseData.Name,
+				SolarSystem: structure.SolarSystem,
+			}, nil
+		}
+	}
+	return b.location(corp.ctx(), locationID)
+}
+
+// fuelStock returns areas with structures, their fuel stock and burn,
+// sorted from the least covered.
+func (b *fuelBot) fuelStock(structs []structureData) ([]stockArea, error) {
+	areas := make(map[int32]*stockArea)
+	area := func(system solarSystem) *stockArea {
+		id, name := system.ID, system.Name
+		if b.cfg.Stock.Coverage == StockCoverageRegion {
+			id, name = system.RegionID, system.RegionName
+		}
+		a, ok := areas[id]
+		if !ok {
+			a = &stockArea{ID: id, Name: name}
+			areas[id] = a
+		}
+		return a
+	}
+
+	for _, structure := range structs {
+		structureType := b.structureByTypeID(structure.CorporationData.TypeId)
+		area(structure.SolarSystem).Burn += b.structureFuelPerDay(structure, structureType)
+	}
+
+	var loaded bool
+	for _, corp := range b.loadedCorporations(structs) {
+		b.cacheMu.Lock()
+		assets := b.assets[corp.ID]
+		b.cacheMu.Unlock()
+		if assets == nil {
+			continue
+		}
+		loaded = true
+
+		for key, blocks := range hangarStock(assets) {
+			loc, err := b.hangarLocation(corp, structs, key.LocationID)
+			if err != nil {
+				b.log.Errorw("Error loading hangar location", "location_id", key.LocationID, "error", err)
+				continue
+			}
+			a := area(loc.SolarSystem)
+			a.Blocks += blocks
+			a.Hangars = append(a.Hangars, stockHangar{
+				Location: loc,
+				Division: key.Division,
+				Blocks:   blocks,
+			})
+		}
+	}
+	if !loaded {
+		return nil, errors.New("no corporation assets loaded")
+	}
+
+	var out []stockArea
+	for _, a := range areas {
+		// Stock without structures to feed is not interesting.
+		if a.Burn == 0 {
+			continue
+		}
+		sort.Slice(a.Hangars, func(i, j int) bool {
+			return a.Hangars[i].Blocks > a.Hangars[j].Blocks
+		})
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Days() < out[j].Days()
+	})
+	return out, nil
+}
+
+// loadedCorporations returns corporations owning given structures.
+func (b *fuelBot) loadedCorporations(structs []structureData) []corporation {
+	var (
+		out  []corporation
+		seen = make(map[int32]bool)
+	)
+	for _, structure := range structs {
+		if seen[structure.Corporation.ID] {
+			continue
+		}
+		seen[structure.Corporation.ID] = true
+		out = append(out, structure.Corporation)
+	}
+	return out
+}
+
+// checkStock sends warning for areas where stock covers fewer than
+// configured days.
+func (b *fuelBot) checkStock(structs []structureData) {
+	if b.cfg.Stock.Days == 0 || len(structs) == 0 {
+		return
+	}
+	areas, err := b.fuelStock(structs)
+	if err != nil {
+		b.log.Errorw("Error computing fuel stock", "error", err)
+		return
+	}
+	for _, area := range areas {
+		if area.Days() >= float64(b.cfg.Stock.Days) {
+			continue
+		}
+
+		b.stateMu.Lock()
+		notification, ok := b.state.StockNotified[int64(area.ID)]
+		b.stateMu.Unlock()
+		if ok && time.Since(notification.At) < b.cfg.Stock.Interval {
+			continue
+		}
+
+		b.log.Infow("Sending stock message",
+			"area", area.Name,
+			"days", area.Days(),
+		)
+		// Hangar stock is not about single structure, default notifier gets it.
+		err := b.send(target{}, "", b.stockMessage([]stockArea{area}, "Fuel stock running low, HAUL IT!")[0])
+		if err != nil {
+			continue
+		}
+
+		b.stateMu.Lock()
+		b.state.StockNotified[int64(area.ID)] = state.Notification{At: time.Now()}
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+// stockReport loads structures and corporation assets and returns
+// fuel stock of all areas.
+func (b *fuelBot) stockReport() ([]*discordgo.MessageEmbed, error) {
+	structs, err := b.loadStructures()
+	if err != nil {
+		return nil, err
+	}
+	areas, err := b.fuelStock(structs)
+	if err != nil {
+		return nil, err
+	}
+	if len(areas) == 0 {
+		return nil, errors.New("no fuelled structures found")
+	}
+	return b.stockMessage(areas, "Fuel stock"), nil
+}
+
+func (b *fuelBot) stockMessage(areas []stockArea, title string) []*discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+	for _, area := range areas {
+		symbol := ":green_square:"
+		if area.Days() < float64(b.cfg.Stock.Days) {
+			symbol = ":red_square:"
+		}
+
+		var value strings.Builder
+		fmt.Fprintf(&value, "**Stock**: %s blocks (%s) \n**Fuel per day**: %.0f",
+			humanize.Comma(area.Blocks),
+			formatDuration(time.Duration(area.Days()*float64(24*time.Hour))),
+			area.Burn,
+		)
+		for _, hangar := range area.Hangars {
+			line := fmt.Sprintf(" \n`%s` Division %d: %s",
+				hangar.Location.Name,
+				hangar.Division,
+				humanize.Comma(hangar.Blocks),
+			)
+			// Discord field value limit is 1024 characters.
+			if value.Len()+len(line) > 1000 {
+				value.WriteString(" \n...")
+				break
+			}
+			value.WriteString(line)
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s %s", symbol, area.Name),
+			Value: value.String(),
+		})
+	}
+
+	return splitEmbed(newEmbed(title, 0xffa500, fields))
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antihax/goesi/esi"
+)
+
+func TestHangarStock(t *testing.T) {
+	const (
+		stationID   = 60003760
+		containerID = 1
+	)
+	assets := []asset{
+		{ItemId: 10, TypeId: heliumFuelBlockTypeID, LocationId: stationID, LocationFlag: "CorpSAG1", Quantity: 1000},
+		{ItemId: containerID, TypeId: 17366, LocationId: stationID, LocationFlag: "CorpSAG2", Quantity: 1},
+		{ItemId: 11, TypeId: oxygenFuelBlockTypeID, LocationId: containerID, LocationFlag: "Unlocked", Quantity: 500},
+		{ItemId: 12, TypeId: oxygenFuelBlockTypeID, LocationId: stationID, LocationFlag: "CorpSAG2", Quantity: 250},
+		// Fuel bay is not stock.
+		{ItemId: 13, TypeId: oxygenFuelBlockTypeID, LocationId: 1022734985679, LocationFlag: locationFlagStructureFuel, Quantity: 5000},
+		// Not a fuel block.
+		{ItemId: 14, TypeId: 34, LocationId: stationID, LocationFlag: "CorpSAG1", Quantity: 1000000},
+	}
+
+	stock := hangarStock(assets)
+	expected := map[hangarKey]int64{
+		{LocationID: stationID, Division: 1}: 1000,
+		{LocationID: stationID, Division: 2}: 750,
+	}
+	if len(stock) != len(expected) {
+		t.Fatalf("expected %d hangars, got: %v", len(expected), stock)
+	}
+	for key, blocks := range expected {
+		if stock[key] != blocks {
+			t.Errorf("%+v: expected %d blocks, got: %d", key, blocks, stock[key])
+		}
+	}
+}
+
+func TestHangarLocation(t *testing.T) {
+	// No ESI client, lookups must not reach it.
+	b := &fuelBot{
+		locations:    make(map[int64]location),
+		locationErrs: map[int64]time.Time{2: time.Now()},
+	}
+	system := solarSystem{ID: 30000142, Name: "Jita"}
+	structs := []structureData{{
+		SolarSystem:     system,
+		CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 1},
+		UniverseData:    esi.GetUniverseStructuresStructureIdOk{Name: "Jita - Astrahus"},
+	}}
+
+	loc, err := b.hangarLocation(corporation{}, structs, 1)
+	if err != nil || loc.Name != "Jita - Astrahus" || loc.SolarSystem != system {
+		t.Errorf("expected location of loaded structure, got: %+v, %v", loc, err)
+	}
+	_, err = b.hangarLocation(corporation{}, structs, 2)
+	if err == nil {
+		t.Error("expected recently failed lookup not to be repeated")
+	}
+}
@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Structure states reported by ESI.
+const (
+	structureStateAnchorVulnerable = "anchor_vulnerable"
+	structureStateAnchoring        = "anchoring"
+	structureStateArmorReinforce   = "armor_reinforce"
+	structureStateArmorVulnerable  = "armor_vulnerable"
+	structureStateHullReinforce    = "hull_reinforce"
+	structureStateHullVulnerable   = "hull_vulnerable"
+	structureStateUnanchored       = "unanchored"
+)
+
+// Structure states not reported by ESI, derived from other fields.
+const (
+	structureStateUnanchoring = "unanchoring"
+	structureStateLowPower    = "low_power"
+	structureStateAbandoned   = "abandoned"
+)
+
+// abandonedAfter is how long structure has to be in low power to
+// become abandoned.
+const abandonedAfter = 7 * 24 * time.Hour
+
+type structureStateInfo struct {
+	Title string
+	Color int
+	// Critical states are sent within quiet hours.
+	Critical bool
+}
+
+// alertStates are states which are notified when structure enters them.
+var alertStates = map[string]structureStateInfo{
+	structureStateArmorReinforce: {
+		Title:    "Shield down, armor reinforced!",
+		Color:    0xffa500,
+		Critical: true,
+	},
+	structureStateArmorVulnerable: {
+		Title:    "Armor vulnerable!",
+		Color:    0xff4500,
+		Critical: true,
+	},
+	structureStateHullReinforce: {
+		Title:    "Armor down, hull reinforced!",
+		Color:    0xff4500,
+		Critical: true,
+	},
+	structureStateHullVulnerable: {
+		Title:    "Hull vulnerable!",
+		Color:    0xff0000,
+		Critical: true,
+	},
+	structureStateAnchoring: {
+		Title: "Structure anchoring",
+		Color: 0x1e90ff,
+	},
+	structureStateAnchorVulnerable: {
+		Title:    "Structure anchoring, vulnerable!",
+		Color:    0xff4500,
+		Critical: true,
+	},
+	structureStateUnanchoring: {
+		Title: "Structure unanchoring",
+		Color: 0x1e90ff,
+	},
+	structureStateUnanchored: {
+		Title: "Structure unanchored",
+		Color: 0x808080,
+	},
+	structureStateLowPower: {
+		Title:    "Structure went low power!",
+		Color:    0xff0000,
+		Critical: true,
+	},
+	structureStateAbandoned: {
+		Title:    "Structure abandoned!",
+		Color:    0x000001, // 0 is no color.
+		Critical: true,
+	},
+}
+
+// checkStates sends notification for structures which entered
+// one of alertStates since the last check. The first check only
+// remembers states, so the bot does not alert about every structure
+// already reinforced or in low power when it starts for the first time.
+func (b *fuelBot) checkStates(structs []structureData) {
+	b.stateMu.Lock()
+	seed := len(b.state.States) == 0
+	b.stateMu.Unlock()
+	for _, structure := range structs {
+		id := structure.CorporationData.StructureId
+		current := b.structureState(structure)
+
+		b.stateMu.Lock()
+		previous, known := b.state.States[id]
+		b.stateMu.Unlock()
+		if known && previous == current {
+			continue
+		}
+
+		info, alert := alertStates[current]
+		if alert && !seed {
+			b.log.Infow("Sending state message",
+				"structure_id", id,
+				"structure_name", structure.UniverseData.Name,
+				"previous_state", previous,
+				"state", current,
+			)
+			err := b.sendMessage(b.structureTarget(structure), notify.Message{
+				Embed:    b.stateMessage(&structure, current, info),
+				Critical: info.Critical,
+			})
+			if err != nil {
+				// State is not updated, so it is picked up on next iteration.
+				continue
+			}
+		}
+
+		b.stateMu.Lock()
+		b.state.States[id] = current
+		b.saveState()
+		b.stateMu.Unlock()
+	}
+}
+
+// structureState returns state reported by ESI, or derived unanchoring,
+// low power or abandoned state.
+func (b *fuelBot) structureState(structure structureData) string {
+	id := structure.CorporationData.StructureId
+	state := structure.CorporationData.State
+	if _, reinforced := alertStates[state]; reinforced {
+		return state
+	}
+	if !structure.CorporationData.UnanchorsAt.IsZero() {
+		return structureStateUnanchoring
+	}
+
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if !structure.CorporationData.FuelExpires.IsZero() {
+		delete(b.state.LowPowerSince, id)
+		return state
+	}
+	since, ok := b.state.LowPowerSince[id]
+	if !ok {
+		since = b.lowPowerSince(structure)
+		b.state.LowPowerSince[id] = since
+		b.saveState()
+	}
+	if time.Since(since) > abandonedAfter {
+		return structureStateAbandoned
+	}
+	return structureStateLowPower
+}
+
+// lowPowerSince estimates when structure ran out of fuel from its last
+// known fuel expiration, or from when it entered its current state,
+// b.stateMu must be held.
+func (b *fuelBot) lowPowerSince(structure structureData) time.Time {
+	expires := b.state.FuelExpires[structure.CorporationData.StructureId]
+	if !expires.IsZero() && expires.Before(time.Now()) {
+		return expires
+	}
+	start := structure.CorporationData.StateTimerStart
+	if !start.IsZero() && start.Before(time.Now()) {
+		return start
+	}
+	return time.Now()
+}
+
+func (b *fuelBot) stateMessage(structure *structureData, state string, info structureStateInfo) *discordgo.MessageEmbed {
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:  "Where?!",
+			Value: fmt.Sprintf("`%s`", structure.UniverseData.Name),
+		},
+		{
+			Name:  "Whose?!",
+			Value: fmt.Sprintf("`%s` [%s]", structure.Corporation.Name, structure.Corporation.Ticker),
+		},
+		{
+			Name:  "State",
+			Value: fmt.Sprintf("`%s`", state),
+		},
+	}
+
+	if state == structureStateLowPower || state == structureStateAbandoned {
+		b.stateMu.Lock()
+		since := b.state.LowPowerSince[structure.CorporationData.StructureId]
+		b.stateMu.Unlock()
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: "Low power since",
+			Value: fmt.Sprintf("%s (%s), estimated",
+				formatTime(since),
+				since,
+			),
+		})
+	}
+
+	timerEnd := structure.CorporationData.StateTimerEnd
+	if state == structureStateUnanchoring {
+		timerEnd = structure.CorporationData.UnanchorsAt
+	}
+	if !timerEnd.IsZero() {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: "Timer ends",
+			Value: fmt.Sprintf("%s (%s)",
+				formatTime(timerEnd),
+				timerEnd,
+			),
+		})
+	}
+
+	return newEmbed(info.Title, info.Color, fields)
+}
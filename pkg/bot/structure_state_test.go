@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-fuelbot/pkg/sde"
+	"github.com/lunemec/eve-fuelbot/pkg/state"
+
+	"github.com/antihax/goesi/esi"
+	"go.uber.org/zap"
+)
+
+func TestCheckStates(t *testing.T) {
+	notifier := &recordNotifier{}
+	b := &fuelBot{
+		log:          zap.NewNop().Sugar(),
+		sde:          &sde.Data{},
+		notifier:     notifier,
+		stateStorage: state.NewMemoryStorage(),
+		state:        state.New(),
+	}
+	ranOut := time.Now().Add(-2 * 24 * time.Hour)
+	b.state.FuelExpires[1] = ranOut
+	structs := []structureData{
+		{CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 1, State: "shield_vulnerable"}},
+		{CorporationData: esi.GetCorporationsCorporationIdStructures200Ok{StructureId: 2, State: structureStateArmorReinforce}},
+	}
+
+	b.checkStates(structs)
+	if len(notifier.msgs) != 0 {
+		t.Errorf("first check should only remember states, got: %d messages", len(notifier.msgs))
+	}
+	if !b.state.LowPowerSince[1].Equal(ranOut) {
+		t.Errorf("expected low power since last fuel expiration, got: %s", b.state.LowPowerSince[1])
+	}
+
+	structs[1].CorporationData.State = structureStateHullReinforce
+	b.checkStates(structs)
+	if len(notifier.msgs) != 1 || notifier.msgs[0].Embed.Title != alertStates[structureStateHullReinforce].Title {
+		t.Errorf("expected hull reinforce message, got: %+v", notifier.msgs)
+	}
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Threshold is one step of escalating fuel notifications. The closer
+// the structure is to running out of fuel, the louder the notification.
+type Threshold struct {
+	// Before is how long before running out of fuel this threshold applies.
+	Before time.Duration `mapstructure:"before"`
+	// Interval is how often the notification is repeated.
+	Interval time.Duration `mapstructure:"interval"`
+	// Color of the notification embed.
+	Color int `mapstructure:"color"`
+	// Mention is prepended to the notification, eg. "@here" or "<@&ROLE_ID>".
+	Mention string `mapstructure:"mention"`
+	// Critical notifications are sent within quiet hours.
+	Critical bool `mapstructure:"critical"`
+}
+
+// Thresholds sorted from the most urgent one.
+type Thresholds []Threshold
+
+// NewThresholds validates and sorts thresholds.
+func NewThresholds(thresholds []Threshold) (Thresholds, error) {
+	out := make(Thresholds, len(thresholds))
+	copy(out, thresholds)
+	for _, threshold := range out {
+		if threshold.Before <= 0 {
+			return nil, errors.Errorf("threshold before must be positive, got: %s", threshold.Before)
+		}
+		if threshold.Interval <= 0 {
+			return nil, errors.Errorf("threshold interval must be positive, got: %s", threshold.Interval)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Before < out[j].Before
+	})
+	return out, nil
+}
+
+// find returns the most urgent threshold for fuel remaining.
+func (t Thresholds) find(remaining time.Duration) (Threshold, bool) {
+	for _, threshold := range t {
+		if remaining <= threshold.Before {
+			return threshold, true
+		}
+	}
+	return Threshold{}, false
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdsFind(t *testing.T) {
+	day := 24 * time.Hour
+	thresholds, err := NewThresholds([]Threshold{
+		{Before: 7 * day, Interval: day},
+		{Before: 6 * time.Hour, Interval: time.Hour},
+		{Before: 14 * day, Interval: 2 * day},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remaining time.Duration
+		before    time.Duration
+		found     bool
+	}{
+		{remaining: 20 * day, found: false},
+		{remaining: 14 * day, before: 14 * day, found: true},
+		{remaining: 10 * day, before: 14 * day, found: true},
+		{remaining: 3 * day, before: 7 * day, found: true},
+		{remaining: time.Hour, before: 6 * time.Hour, found: true},
+	}
+	for _, test := range tests {
+		threshold, found := thresholds.find(test.remaining)
+		if found != test.found || threshold.Before != test.before {
+			t.Errorf("find(%s) = %s, %t; expected %s, %t", test.remaining, threshold.Before, found, test.before, test.found)
+		}
+	}
+}
+
+func TestNewThresholdsInvalid(t *testing.T) {
+	_, err := NewThresholds([]Threshold{{Before: time.Hour}})
+	if err == nil {
+		t.Fatal("expected error for threshold without interval")
+	}
+}
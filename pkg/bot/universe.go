@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type solarSystem struct {
+	ID         int32
+	Name       string
+	RegionID   int32
+	RegionName string
+}
+
+// location is a station, structure or moon.
+type location struct {
+	ID          int64
+	Name        string
+	SolarSystem solarSystem
+}
+
+// NPC station and moon IDs are in these ranges, anything above is
+// a structure.
+const (
+	stationIDMin = 60000000
+	stationIDMax = 64000000
+	moonIDMin    = 40000000
+	moonIDMax    = 50000000
+)
+
+// solarSystem returns solar system information, cached for the lifetime
+// of the bot as it does not change.
+func (b *fuelBot) solarSystem(ctx context.Context, systemID int32) (solarSystem, error) {
+	b.cacheMu.Lock()
+	system, ok := b.systems[systemID]
+	b.cacheMu.Unlock()
+	if ok {
+		return system, nil
+	}
+
+	systemInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseSystemsSystemId(ctx, systemID, nil)
+	if err != nil {
+		return solarSystem{}, errors.Wrapf(err, "unable to load solar system info for system: %d", systemID)
+	}
+	constellationInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseConstellationsConstellationId(ctx, systemInfo.ConstellationId, nil)
+	if err != nil {
+		return solarSystem{}, errors.Wrapf(err, "unable to load constellation info for constellation: %d", systemInfo.ConstellationId)
+	}
+	regionInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseRegionsRegionId(ctx, constellationInfo.RegionId, nil)
+	if err != nil {
+		return solarSystem{}, errors.Wrapf(err, "unable to load region info for region: %d", constellationInfo.RegionId)
+	}
+	system = solarSystem{
+		ID:         systemID,
+		Name:       systemInfo.Name,
+		RegionID:   constellationInfo.RegionId,
+		RegionName: regionInfo.Name,
+	}
+
+	b.cacheMu.Lock()
+	b.systems[systemID] = system
+	b.cacheMu.Unlock()
+	return system, nil
+}
+
+// locationRetry is how long failed location lookup is not repeated,
+// structures without docking access fail every time.
+const locationRetry = 24 * time.Hour
+
+// location returns station, structure or moon information, cached for the
+// lifetime of the bot. Structures need ctx authenticated with character
+// that has docking access, failed lookups are retried after locationRetry.
+func (b *fuelBot) location(ctx context.Context, locationID int64) (location, error) {
+	b.cacheMu.Lock()
+	loc, ok := b.locations[locationID]
+	failed, failedOK := b.locationErrs[locationID]
+	b.cacheMu.Unlock()
+	if ok {
+		return loc, nil
+	}
+	if failedOK && time.Since(failed) < locationRetry {
+		return location{}, errors.Errorf("lookup of location: %d failed recently, retrying after %s", locationID, failed.Add(locationRetry))
+	}
+
+	loc, err := b.loadLocation(ctx, locationID)
+	b.cacheMu.Lock()
+	if err != nil {
+		b.locationErrs[locationID] = time.Now()
+	} else {
+		delete(b.locationErrs, locationID)
+		b.locations[locationID] = loc
+	}
+	b.cacheMu.Unlock()
+	return loc, err
+}
+
+func (b *fuelBot) loadLocation(ctx context.Context, locationID int64) (location, error) {
+
+	var (
+		name     string
+		systemID int32
+	)
+	switch {
+	case locationID >= moonIDMin && locationID < moonIDMax:
+		moonInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseMoonsMoonId(ctx, int32(locationID), nil)
+		if err != nil {
+			return location{}, errors.Wrapf(err, "unable to load moon info for moon: %d", locationID)
+		}
+		name, systemID = moonInfo.Name, moonInfo.SystemId
+	case locationID >= stationIDMin && locationID < stationIDMax:
+		stationInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseStationsStationId(ctx, int32(locationID), nil)
+		if err != nil {
+			return location{}, errors.Wrapf(err, "unable to load station info for station: %d", locationID)
+		}
+		name, systemID = stationInfo.Name, stationInfo.SystemId
+	default:
+		structureInfo, _, err := b.esi.ESI.UniverseApi.GetUniverseStructuresStructureId(ctx, locationID, nil)
+		if err != nil {
+			return location{}, errors.Wrapf(err, "unable to load strucutre info for structure: %d", locationID)
+		}
+		name, systemID = structureInfo.Name, structureInfo.SolarSystemId
+	}
+	system, err := b.solarSystem(ctx, systemID)
+	if err != nil {
+		return location{}, err
+	}
+	return location{
+		ID:          locationID,
+		Name:        name,
+		SolarSystem: system,
+	}, nil
+}
@@ -0,0 +1,19 @@
+package esipage
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Count returns number of pages from ESI X-Pages header, 1 when the
+// response has none.
+func Count(resp *http.Response) int32 {
+	if resp == nil {
+		return 1
+	}
+	pages, err := strconv.Atoi(resp.Header.Get("X-Pages"))
+	if err != nil || pages < 1 {
+		return 1
+	}
+	return int32(pages)
+}
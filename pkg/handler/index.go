@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"syscall"
 	"time"
@@ -10,7 +11,7 @@ import (
 )
 
 func (h *handler) indexHandler(w http.ResponseWriter, r *http.Request) error {
-	_, err := h.character(r)
+	character, err := h.character(r)
 	if err != nil {
 		http.Redirect(w, r, "/login", http.StatusFound)
 		return nil
@@ -23,11 +24,12 @@ func (h *handler) indexHandler(w http.ResponseWriter, r *http.Request) error {
 
 	session := h.session(r)
 	token := session.Values["token"].(oauth2.Token)
-	err = h.tokenStorage.Write(token)
+	err = h.tokenStorage.Write(character.CharacterName, token)
 	if err != nil {
 		return errors.Wrap(err, "unable to save token")
 	}
-	_, _ = w.Write([]byte("logged in successfully"))
+	h.log.Infow("character added", "character", character.CharacterName)
+	_, _ = w.Write([]byte(fmt.Sprintf("logged in successfully as %s", character.CharacterName)))
 	// Spawn a goroutine that will send SIGTEM in 1s.
 	go func() {
 		time.Sleep(1 * time.Second)
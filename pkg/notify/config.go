@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// Notifier types.
+const (
+	TypeDiscord        = "discord"
+	TypeDiscordWebhook = "discord_webhook"
+	TypeSlack          = "slack"
+	TypeTelegram       = "telegram"
+	TypeEmail          = "email"
+	TypeWebhook        = "webhook"
+)
+
+// Config of single notifier, Type selects which fields are used.
+type Config struct {
+	// Name routes refer to the notifier by.
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"`
+	// ChannelID for discord.
+	ChannelID string `mapstructure:"channel_id"`
+	// URL for discord_webhook, slack and webhook.
+	URL string `mapstructure:"url"`
+	// Token and ChatID for telegram.
+	Token  string `mapstructure:"token"`
+	ChatID string `mapstructure:"chat_id"`
+	// SMTP server and addresses for email.
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+	// QuietHours defer non-critical messages, optional for all types.
+	QuietHours QuietHoursConfig `mapstructure:"quiet_hours"`
+}
+
+// QuietHoursConfig is daily window like "22:00" to "08:00" in IANA
+// Timezone, eg. "Europe/Prague".
+type QuietHoursConfig struct {
+	Start    string `mapstructure:"start"`
+	End      string `mapstructure:"end"`
+	Timezone string `mapstructure:"timezone"`
+}
+
+// New returns notifier described by cfg, messages deferred by its quiet
+// hours are kept in queue.
+func New(cfg Config, client *http.Client, session *discordgo.Session, queue *Queue) (Notifier, error) {
+	notifier, err := newNotifier(cfg, client, session)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.QuietHours == (QuietHoursConfig{}) {
+		return notifier, nil
+	}
+	if cfg.Name == "" {
+		// Deferred messages are stored by name to survive restarts.
+		return nil, errors.New("notifier with quiet_hours needs name")
+	}
+	hours, err := ParseQuietHours(cfg.QuietHours.Start, cfg.QuietHours.End, cfg.QuietHours.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuiet(notifier, cfg.Name, hours, queue), nil
+}
+
+func newNotifier(cfg Config, client *http.Client, session *discordgo.Session) (Notifier, error) {
+	switch cfg.Type {
+	case TypeDiscord:
+		if cfg.ChannelID == "" {
+			return nil, errors.New("discord notifier needs channel_id")
+		}
+		return NewDiscord(session, cfg.ChannelID), nil
+	case TypeDiscordWebhook, TypeSlack, TypeWebhook:
+		if cfg.URL == "" {
+			return nil, errors.Errorf("%s notifier needs url", cfg.Type)
+		}
+		switch cfg.Type {
+		case TypeDiscordWebhook:
+			return NewDiscordWebhook(client, cfg.URL), nil
+		case TypeSlack:
+			return NewSlack(client, cfg.URL), nil
+		}
+		return NewWebhook(client, cfg.URL), nil
+	case TypeTelegram:
+		if cfg.Token == "" || cfg.ChatID == "" {
+			return nil, errors.New("telegram notifier needs token and chat_id")
+		}
+		return NewTelegram(client, TelegramAPIURL, cfg.Token, cfg.ChatID), nil
+	case TypeEmail:
+		if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, errors.New("email notifier needs host, from and to")
+		}
+		port := cfg.Port
+		if port == 0 {
+			port = 25
+		}
+		return NewEmail(cfg.Host, port, cfg.Username, cfg.Password, cfg.From, cfg.To), nil
+	}
+	return nil, errors.Errorf("unknown notifier type: %s", cfg.Type)
+}
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+type discordNotifier struct {
+	session   *discordgo.Session
+	channelID string
+}
+
+// NewDiscord returns notifier sending alerts with the bot to channel.
+func NewDiscord(session *discordgo.Session, channelID string) Notifier {
+	return &discordNotifier{
+		session:   session,
+		channelID: channelID,
+	}
+}
+
+func (d *discordNotifier) Notify(msg Message) error {
+	_, err := d.session.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
+		Content:    msg.Content,
+		Embeds:     embeds(msg),
+		Components: msg.Components,
+	})
+	return errors.Wrapf(err, "error sending discord message to channel: %s", d.channelID)
+}
+
+type discordWebhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewDiscordWebhook returns notifier sending alerts to Discord webhook url.
+// Message components are dropped, webhooks not owned by an application
+// cannot send buttons.
+func NewDiscordWebhook(client *http.Client, url string) Notifier {
+	return &discordWebhookNotifier{
+		client: client,
+		url:    url,
+	}
+}
+
+func (d *discordWebhookNotifier) Notify(msg Message) error {
+	err := postJSON(d.client, d.url, discordgo.WebhookParams{
+		Content: msg.Content,
+		Embeds:  embeds(msg),
+	})
+	return errors.Wrap(err, "error sending discord webhook")
+}
+
+// embeds returns msg embed as list Discord expects, empty for text only
+// messages.
+func embeds(msg Message) []*discordgo.MessageEmbed {
+	if msg.Embed == nil {
+		return nil
+	}
+	return []*discordgo.MessageEmbed{msg.Embed}
+}
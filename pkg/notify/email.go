@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type emailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmail returns notifier sending alerts as plain text email through
+// SMTP server, auth is not used when username is empty.
+func NewEmail(host string, port int, username, password, from string, to []string) Notifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &emailNotifier{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+func (e *emailNotifier) Notify(msg Message) error {
+	subject := "EVE FuelBot"
+	if msg.Embed != nil && msg.Embed.Title != "" {
+		subject = msg.Embed.Title
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(plainText(msg), "\n", "\r\n"))
+
+	err := smtp.SendMail(e.addr, e.auth, e.from, e.to, []byte(b.String()))
+	return errors.Wrapf(err, "error sending email through: %s", e.addr)
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// Message is alert delivered by Notifier. Embed is Discord embed, other
+// notifiers convert it to their own format.
+type Message struct {
+	// Content is text outside of the embed, eg. mentions.
+	Content string
+	Embed   *discordgo.MessageEmbed
+	// Components are buttons, only notifiers sending as the bot show them.
+	Components []discordgo.MessageComponent
+	// Critical messages are sent within quiet hours.
+	Critical bool
+	// TargetID is structure or starbase the message is about, deferred
+	// messages are dropped by it when no longer relevant.
+	TargetID int64
+}
+
+// Notifier delivers alerts somewhere.
+type Notifier interface {
+	Notify(Message) error
+}
+
+type multi struct {
+	notifiers []Notifier
+}
+
+// NewMulti returns notifier delivering alerts to all notifiers. When only
+// some of them fail, it returns *PartialError.
+func NewMulti(notifiers ...Notifier) Notifier {
+	return &multi{
+		notifiers: notifiers,
+	}
+}
+
+func (m *multi) Notify(msg Message) error {
+	var errs []string
+	for _, notifier := range m.notifiers {
+		err := notifier.Notify(msg)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == len(m.notifiers) {
+		return errors.Errorf("all notifiers failed: %s", strings.Join(errs, "; "))
+	}
+	return &PartialError{Errs: errs}
+}
+
+// PartialError means message was delivered, but some notifiers failed,
+// callers should log it and not send the message again.
+type PartialError struct {
+	Errs []string
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("some notifiers failed: %s", strings.Join(e.Errs, "; "))
+}
+
+// postJSON posts body encoded as JSON to url and fails on non 2xx response.
+func postJSON(client *http.Client, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "error encoding request")
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "error sending request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected response status: %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+// plainText returns message as text without Discord markdown.
+func plainText(msg Message) string {
+	var b strings.Builder
+	if msg.Content != "" {
+		b.WriteString(msg.Content)
+		b.WriteString("\n")
+	}
+	if msg.Embed == nil {
+		return b.String()
+	}
+	if msg.Embed.Title != "" {
+		b.WriteString(msg.Embed.Title)
+		b.WriteString("\n")
+	}
+	if msg.Embed.Description != "" {
+		b.WriteString(stripMarkdown(msg.Embed.Description))
+		b.WriteString("\n")
+	}
+	for _, field := range msg.Embed.Fields {
+		fmt.Fprintf(&b, "\n%s\n%s\n", stripMarkdown(field.Name), stripMarkdown(field.Value))
+	}
+	return b.String()
+}
+
+var markdownReplacer = strings.NewReplacer(
+	"**", "",
+	"`", "",
+	" \n", "\n",
+)
+
+func stripMarkdown(s string) string {
+	return strings.TrimSpace(markdownReplacer.Replace(relativeTimes(s)))
+}
+
+// discordTimestamp matches Discord relative timestamp, eg. "<t:1620000000:R>".
+var discordTimestamp = regexp.MustCompile(`<t:(-?\d+):R>`)
+
+// relativeTimes replaces Discord relative timestamps, which other services
+// do not render, with time relative to now.
+func relativeTimes(s string) string {
+	return discordTimestamp.ReplaceAllStringFunc(s, func(match string) string {
+		unix, err := strconv.ParseInt(discordTimestamp.FindStringSubmatch(match)[1], 10, 64)
+		if err != nil {
+			return match
+		}
+		return humanize.Time(time.Unix(unix, 0))
+	})
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var testMessage = Message{
+	Content: "@here",
+	Embed: &discordgo.MessageEmbed{
+		Title: "Citadel running out of fuel, FEED IT!",
+		Color: 0xff0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Where?!", Value: "`Jita - Astrahus`"},
+			{Name: "Fuel", Value: "**Fuel bay**: 100 blocks"},
+		},
+	},
+}
+
+func TestPlainText(t *testing.T) {
+	expected := "@here\nCitadel running out of fuel, FEED IT!\n\nWhere?!\nJita - Astrahus\n\nFuel\nFuel bay: 100 blocks\n"
+	if text := plainText(testMessage); text != expected {
+		t.Errorf("unexpected text: %q", text)
+	}
+}
+
+func TestRelativeTimes(t *testing.T) {
+	in := fmt.Sprintf("expires <t:%d:R> (2021-05-11)", time.Now().Add(49*time.Hour).Unix())
+	if out := relativeTimes(in); out != "expires 2 days from now (2021-05-11)" {
+		t.Errorf("unexpected text: %q", out)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Error(err)
+		}
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	err := NewSlack(server.Client(), server.URL).Notify(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment := body["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["color"] != "#ff0000" || body["text"] != "@here" {
+		t.Errorf("unexpected slack message: %+v", body)
+	}
+	field := attachment["fields"].([]interface{})[1].(map[string]interface{})
+	if field["value"] != "*Fuel bay*: 100 blocks" {
+		t.Errorf("unexpected slack field: %+v", field)
+	}
+
+	err = NewWebhook(server.Client(), server.URL).Notify(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["title"] != testMessage.Embed.Title || len(body["fields"].([]interface{})) != 2 {
+		t.Errorf("unexpected webhook message: %+v", body)
+	}
+
+	err = NewTelegram(server.Client(), server.URL, "secret", "42").Notify(testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["chat_id"] != "42" || body["text"] != plainText(testMessage) {
+		t.Errorf("unexpected telegram message: %+v", body)
+	}
+
+	body = nil
+	err = NewDiscordWebhook(server.Client(), server.URL).Notify(Message{Content: "@here"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["embeds"]; ok || body["content"] != "@here" {
+		t.Errorf("unexpected text only discord webhook message: %+v", body)
+	}
+
+	multi := NewMulti(NewWebhook(server.Client(), server.URL+"/fail"), NewWebhook(server.Client(), server.URL))
+	var partial *PartialError
+	if err := multi.Notify(testMessage); !errors.As(err, &partial) || len(partial.Errs) != 1 {
+		t.Errorf("multi should return partial error when one notifier works: %v", err)
+	}
+	multi = NewMulti(NewWebhook(server.Client(), server.URL+"/fail"))
+	if err := multi.Notify(testMessage); err == nil {
+		t.Error("multi should fail when all notifiers fail")
+	}
+}
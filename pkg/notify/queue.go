@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// Components are interfaces, gob needs to know their types.
+	gob.Register(discordgo.ActionsRow{})
+	gob.Register(discordgo.Button{})
+}
+
+// QueueStorage is interface for accessing deferred messages.
+type QueueStorage interface {
+	Read() (map[string][]Message, error)
+	Write(map[string][]Message) error
+}
+
+// Queue holds messages deferred by quiet hours of each named notifier,
+// so they survive restarts.
+type Queue struct {
+	storage QueueStorage
+
+	mu      sync.Mutex
+	pending map[string][]Message
+}
+
+// NewQueue returns queue with messages loaded from storage.
+func NewQueue(storage QueueStorage) (*Queue, error) {
+	pending, err := storage.Read()
+	if err != nil {
+		return nil, err
+	}
+	return &Queue{
+		storage: storage,
+		pending: pending,
+	}, nil
+}
+
+func (q *Queue) push(name string, msgs ...Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[name] = append(q.pending[name], msgs...)
+	return q.storage.Write(q.pending)
+}
+
+// take removes and returns all messages deferred for notifier name.
+func (q *Queue) take(name string) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msgs := q.pending[name]
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	delete(q.pending, name)
+	return msgs, q.storage.Write(q.pending)
+}
+
+func (q *Queue) len(name string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending[name])
+}
+
+// Drop removes deferred messages about structure or starbase with id,
+// eg. when it was refuelled in the meantime.
+func (q *Queue) Drop(id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var dropped bool
+	for name, msgs := range q.pending {
+		var keep []Message
+		for _, msg := range msgs {
+			if msg.TargetID == id {
+				dropped = true
+				continue
+			}
+			keep = append(keep, msg)
+		}
+		q.pending[name] = keep
+	}
+	if !dropped {
+		return nil
+	}
+	return q.storage.Write(q.pending)
+}
+
+type fileQueueStorage struct {
+	filename string
+}
+
+// NewFileQueueStorage returns deferred message storage in file.
+func NewFileQueueStorage(filename string) QueueStorage {
+	return &fileQueueStorage{
+		filename: filename,
+	}
+}
+
+// Read returns empty queue when the file does not exist yet.
+func (fs *fileQueueStorage) Read() (map[string][]Message, error) {
+	out := make(map[string][]Message)
+	f, err := os.Open(fs.filename)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return out, errors.Wrapf(err, "unable to open file for reading: %s", fs.filename)
+	}
+	defer f.Close()
+	err = gob.NewDecoder(f).Decode(&out)
+	if err != nil {
+		return make(map[string][]Message), errors.Wrap(err, "error decoding deferred messages file")
+	}
+	return out, nil
+}
+
+// Write replaces the file through temporary file, so a crash mid-write
+// does not leave a corrupted file behind.
+func (fs *fileQueueStorage) Write(pending map[string][]Message) error {
+	f, err := os.CreateTemp(filepath.Dir(fs.filename), filepath.Base(fs.filename)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temporary file for: %s", fs.filename)
+	}
+	defer os.Remove(f.Name())
+	err = gob.NewEncoder(f).Encode(pending)
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "error encoding deferred messages file")
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file: %s", f.Name())
+	}
+	return errors.Wrapf(os.Rename(f.Name(), fs.filename), "unable to replace file: %s", fs.filename)
+}
+
+type memoryQueueStorage struct{}
+
+// NewMemoryQueueStorage returns deferred message storage which is lost
+// on restart.
+func NewMemoryQueueStorage() QueueStorage {
+	return memoryQueueStorage{}
+}
+
+func (memoryQueueStorage) Read() (map[string][]Message, error) {
+	return make(map[string][]Message), nil
+}
+
+func (memoryQueueStorage) Write(map[string][]Message) error {
+	return nil
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Timezones of quiet hours work without system tzdata, eg. in docker.
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+)
+
+// QuietHours is daily window in Location when non-critical messages are
+// deferred, Start and End are time since midnight. Window with End
+// before Start spans midnight.
+type QuietHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseQuietHours parses start and end like "22:00" in IANA timezone,
+// eg. "Europe/Prague", empty timezone is local time.
+func ParseQuietHours(start, end, timezone string) (QuietHours, error) {
+	var (
+		quiet QuietHours
+		err   error
+	)
+	quiet.Start, err = parseClock(start)
+	if err != nil {
+		return QuietHours{}, errors.Wrap(err, "invalid quiet hours start")
+	}
+	quiet.End, err = parseClock(end)
+	if err != nil {
+		return QuietHours{}, errors.Wrap(err, "invalid quiet hours end")
+	}
+	quiet.Location, err = time.LoadLocation(timezone)
+	if err != nil {
+		return QuietHours{}, errors.Wrapf(err, "invalid quiet hours timezone: %s", timezone)
+	}
+	return quiet, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, errors.Errorf("expected HH:MM, got: %s", s)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, errors.Errorf("invalid hour: %s", s)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, errors.Errorf("invalid minute: %s", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// quiet returns whether t is within quiet hours, and when they end.
+func (q QuietHours) quiet(t time.Time) (bool, time.Time) {
+	t = t.In(q.Location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.Location)
+	now := t.Sub(midnight)
+
+	var quiet bool
+	if q.Start <= q.End {
+		quiet = now >= q.Start && now < q.End
+	} else {
+		quiet = now >= q.Start || now < q.End
+	}
+	end := midnight.Add(q.End)
+	if !end.After(t) {
+		end = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, q.Location).Add(q.End)
+	}
+	return quiet, end
+}
+
+// quietRetry is how soon deferred messages which failed to send are
+// retried.
+const quietRetry = 5 * time.Minute
+
+type quietNotifier struct {
+	notifier Notifier
+	name     string
+	hours    QuietHours
+	queue    *Queue
+	now      func() time.Time
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// NewQuiet returns notifier which defers non-critical messages sent
+// within quiet hours to queue under name, and sends them when quiet
+// hours end. Messages deferred before restart are sent too.
+func NewQuiet(notifier Notifier, name string, hours QuietHours, queue *Queue) Notifier {
+	q := &quietNotifier{
+		notifier: notifier,
+		name:     name,
+		hours:    hours,
+		queue:    queue,
+		now:      time.Now,
+	}
+	if queue.len(name) > 0 {
+		quiet, end := hours.quiet(q.now())
+		wait := quietRetry
+		if quiet {
+			wait = end.Sub(q.now())
+		}
+		q.schedule(wait)
+	}
+	return q
+}
+
+func (q *quietNotifier) Notify(msg Message) error {
+	quiet, end := q.hours.quiet(q.now())
+	if !quiet {
+		// Messages deferred within quiet hours go first.
+		err := q.flush()
+		if err != nil {
+			return err
+		}
+		return q.notifier.Notify(msg)
+	}
+	if msg.Critical {
+		return q.notifier.Notify(msg)
+	}
+
+	err := q.queue.push(q.name, msg)
+	if err != nil {
+		return errors.Wrap(err, "error deferring message")
+	}
+	q.schedule(end.Sub(q.now()))
+	return nil
+}
+
+// schedule flushes deferred messages after wait, unless flush is
+// already scheduled.
+func (q *quietNotifier) schedule(wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.timer != nil {
+		return
+	}
+	q.timer = time.AfterFunc(wait, func() {
+		q.mu.Lock()
+		q.timer = nil
+		q.mu.Unlock()
+		err := q.flush()
+		if err != nil {
+			q.schedule(quietRetry)
+		}
+	})
+}
+
+// flush sends deferred messages in order, those which failed to send
+// are deferred again.
+func (q *quietNotifier) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	msgs, err := q.queue.take(q.name)
+	if err != nil {
+		return errors.Wrap(err, "error reading deferred messages")
+	}
+	for i, msg := range msgs {
+		err := q.notifier.Notify(msg)
+		if err != nil {
+			// Keep the rest for the next time, pushing them back can
+			// only fail on storage error, which is the same for the next
+			// time.
+			_ = q.queue.push(q.name, msgs[i:]...)
+			return errors.Wrap(err, "error sending deferred message")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type recorder struct {
+	messages []Message
+}
+
+func (r *recorder) Notify(msg Message) error {
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func TestQuietHours(t *testing.T) {
+	hours, err := ParseQuietHours("22:00", "08:00", "Europe/Prague")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prague := hours.Location
+	tests := []struct {
+		at    time.Time
+		quiet bool
+		end   time.Time
+	}{
+		{at: time.Date(2024, 1, 15, 23, 0, 0, 0, prague), quiet: true, end: time.Date(2024, 1, 16, 8, 0, 0, 0, prague)},
+		// 3:00 UTC is 4:00 in Prague.
+		{at: time.Date(2024, 1, 16, 3, 0, 0, 0, time.UTC), quiet: true, end: time.Date(2024, 1, 16, 8, 0, 0, 0, prague)},
+		{at: time.Date(2024, 1, 16, 8, 0, 0, 0, prague), quiet: false},
+		{at: time.Date(2024, 1, 16, 12, 0, 0, 0, prague), quiet: false},
+	}
+	for _, test := range tests {
+		quiet, end := hours.quiet(test.at)
+		if quiet != test.quiet || (quiet && !end.Equal(test.end)) {
+			t.Errorf("quiet(%s) = %t, %s; expected %t, %s", test.at, quiet, end, test.quiet, test.end)
+		}
+	}
+
+	_, err = ParseQuietHours("25:00", "08:00", "")
+	if err == nil {
+		t.Error("expected error for invalid start")
+	}
+}
+
+func TestQuietNotifier(t *testing.T) {
+	hours, err := ParseQuietHours("22:00", "08:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := NewFileQueueStorage(filepath.Join(t.TempDir(), "deferred.bin"))
+	queue, err := NewQueue(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{}
+	q := NewQuiet(r, "fuel", hours, queue).(*quietNotifier)
+	q.now = func() time.Time { return time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC) }
+
+	_ = q.Notify(Message{Content: "deferred", TargetID: 1})
+	_ = q.Notify(Message{Content: "refuelled", TargetID: 2, Components: []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{discordgo.Button{Label: "I'm on it"}}},
+	}})
+	_ = q.Notify(Message{Content: "critical", Critical: true})
+	if len(r.messages) != 1 || r.messages[0].Content != "critical" {
+		t.Fatalf("expected only critical message within quiet hours, got: %+v", r.messages)
+	}
+	err = queue.Drop(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deferred messages survive restart.
+	queue, err = NewQueue(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q = NewQuiet(r, "fuel", hours, queue).(*quietNotifier)
+	q.now = func() time.Time { return time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC) }
+	_ = q.Notify(Message{Content: "morning"})
+	if len(r.messages) != 3 || r.messages[1].Content != "deferred" || r.messages[2].Content != "morning" {
+		t.Errorf("expected deferred message before morning one, got: %+v", r.messages)
+	}
+}
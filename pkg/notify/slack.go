@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type slackNotifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewSlack returns notifier sending alerts to Slack incoming webhook url.
+func NewSlack(client *http.Client, url string) Notifier {
+	return &slackNotifier{
+		client: client,
+		url:    url,
+	}
+}
+
+type slackMessage struct {
+	Text        string            `json:"text,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Title  string       `json:"title,omitempty"`
+	Text   string       `json:"text,omitempty"`
+	Fields []slackField `json:"fields,omitempty"`
+	TS     int64        `json:"ts,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Slack uses single asterisk for bold.
+var slackReplacer = strings.NewReplacer("**", "*")
+
+func (s *slackNotifier) Notify(msg Message) error {
+	out := slackMessage{
+		Text: msg.Content,
+	}
+	if msg.Embed != nil {
+		attachment := slackAttachment{
+			Color: fmt.Sprintf("#%06x", msg.Embed.Color),
+			Title: msg.Embed.Title,
+			Text:  slackReplacer.Replace(relativeTimes(msg.Embed.Description)),
+			TS:    time.Now().Unix(),
+		}
+		for _, field := range msg.Embed.Fields {
+			attachment.Fields = append(attachment.Fields, slackField{
+				Title: slackReplacer.Replace(relativeTimes(field.Name)),
+				Value: slackReplacer.Replace(relativeTimes(field.Value)),
+			})
+		}
+		out.Attachments = []slackAttachment{attachment}
+	}
+	return errors.Wrap(postJSON(s.client, s.url, out), "error sending slack webhook")
+}
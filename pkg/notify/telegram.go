@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TelegramAPIURL is Telegram bot API.
+const TelegramAPIURL = "https://api.telegram.org"
+
+type telegramNotifier struct {
+	client *http.Client
+	url    string
+	token  string
+	chatID string
+}
+
+// NewTelegram returns notifier sending alerts with Telegram bot token to
+// chatID, apiURL is usually TelegramAPIURL.
+func NewTelegram(client *http.Client, apiURL, token, chatID string) Notifier {
+	return &telegramNotifier{
+		client: client,
+		url:    fmt.Sprintf("%s/bot%s/sendMessage", apiURL, token),
+		token:  token,
+		chatID: chatID,
+	}
+}
+
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func (t *telegramNotifier) Notify(msg Message) error {
+	err := postJSON(t.client, t.url, telegramMessage{
+		ChatID: t.chatID,
+		Text:   plainText(msg),
+	})
+	if err != nil {
+		// Do not leak the token in url.
+		return errors.Errorf("error sending telegram message to chat: %s: %s", t.chatID, strings.ReplaceAll(err.Error(), t.token, "***"))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewWebhook returns notifier posting alerts as generic JSON to url.
+func NewWebhook(client *http.Client, url string) Notifier {
+	return &webhookNotifier{
+		client: client,
+		url:    url,
+	}
+}
+
+// WebhookMessage is JSON body posted by webhook notifier.
+type WebhookMessage struct {
+	Content   string         `json:"content,omitempty"`
+	Title     string         `json:"title"`
+	Color     int            `json:"color"`
+	Fields    []WebhookField `json:"fields"`
+	Text      string         `json:"text"`
+	Timestamp string         `json:"timestamp"`
+}
+
+// WebhookField is single embed field of WebhookMessage.
+type WebhookField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (w *webhookNotifier) Notify(msg Message) error {
+	out := WebhookMessage{
+		Content: msg.Content,
+		Text:    plainText(msg),
+	}
+	if msg.Embed != nil {
+		out.Title = msg.Embed.Title
+		out.Color = msg.Embed.Color
+		out.Timestamp = msg.Embed.Timestamp
+		for _, field := range msg.Embed.Fields {
+			out.Fields = append(out.Fields, WebhookField{
+				Name:  stripMarkdown(field.Name),
+				Value: stripMarkdown(field.Value),
+			})
+		}
+	}
+	return errors.Wrap(postJSON(w.client, w.url, out), "error sending webhook")
+}
@@ -0,0 +1,141 @@
+package price
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// retryInterval is how soon failed refresh is retried.
+const retryInterval = 5 * time.Minute
+
+type logger interface {
+	Infow(string, ...interface{})
+	Errorw(string, ...interface{})
+}
+
+// Snapshot of prices at given time.
+type Snapshot struct {
+	Prices map[int32]float64
+	// Updated holds when price of each type was loaded.
+	Updated map[int32]time.Time
+	// UpdatedAt is when the oldest of Prices was loaded.
+	UpdatedAt time.Time
+}
+
+// updated returns when price of typeID was loaded, snapshots saved
+// before per type times fall back to UpdatedAt.
+func (s Snapshot) updated(typeID int32) time.Time {
+	if at, ok := s.Updated[typeID]; ok {
+		return at
+	}
+	return s.UpdatedAt
+}
+
+// Cache refreshes prices from provider in the background and keeps
+// the last known prices when the provider fails.
+type Cache struct {
+	log      logger
+	provider Provider
+	storage  Storage
+	interval time.Duration
+
+	mu       sync.Mutex
+	snapshot Snapshot
+}
+
+// NewCache returns price cache refreshing prices every interval. Last
+// known prices are loaded from storage, so they are available right
+// after restart.
+func NewCache(log logger, provider Provider, storage Storage, interval time.Duration) *Cache {
+	c := &Cache{
+		log:      log,
+		provider: provider,
+		storage:  storage,
+		interval: interval,
+	}
+	snapshot, err := storage.Read()
+	if err != nil {
+		log.Errorw("Error reading price cache", "error", err)
+	}
+	c.snapshot = snapshot
+	return c
+}
+
+// Run refreshes prices of typeIDs forever, call it in goroutine.
+func (c *Cache) Run(typeIDs []int32) {
+	for {
+		wait := c.interval
+		err := c.Refresh(typeIDs)
+		if err != nil {
+			// Log but do not return error, last known prices are used.
+			c.log.Errorw("Error refreshing prices", "error", err)
+			if retryInterval < wait {
+				wait = retryInterval
+			}
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Refresh loads prices of typeIDs from provider and saves them. Types
+// the provider has no price for keep their last known price, so one
+// missing type does not lose prices of the others.
+func (c *Cache) Refresh(typeIDs []int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	prices, err := c.provider.Prices(ctx, typeIDs)
+	if err != nil {
+		return errors.Wrap(err, "unable to load prices")
+	}
+	if len(prices) == 0 {
+		return errors.Errorf("no prices for types: %v", typeIDs)
+	}
+
+	now := time.Now()
+	updated := make(map[int32]time.Time)
+	for typeID := range prices {
+		updated[typeID] = now
+	}
+
+	c.mu.Lock()
+	var missing []int32
+	for _, typeID := range typeIDs {
+		if _, ok := prices[typeID]; ok {
+			continue
+		}
+		missing = append(missing, typeID)
+		if price, ok := c.snapshot.Prices[typeID]; ok {
+			prices[typeID] = price
+			updated[typeID] = c.snapshot.updated(typeID)
+		}
+	}
+	snapshot := Snapshot{
+		Prices:    prices,
+		Updated:   updated,
+		UpdatedAt: now,
+	}
+	for _, at := range updated {
+		if at.Before(snapshot.UpdatedAt) {
+			snapshot.UpdatedAt = at
+		}
+	}
+	c.snapshot = snapshot
+	c.mu.Unlock()
+
+	if len(missing) != 0 {
+		c.log.Errorw("No price for some types, using last known", "types", missing)
+	}
+	c.log.Infow("Prices refreshed", "prices", prices)
+	return errors.Wrap(c.storage.Write(snapshot), "unable to save prices")
+}
+
+// Snapshot returns last known prices, Prices are nil when no prices
+// were loaded yet.
+func (c *Cache) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshot
+}
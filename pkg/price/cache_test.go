@@ -0,0 +1,55 @@
+package price
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCacheRefreshMissingType(t *testing.T) {
+	dir := t.TempDir()
+	priceFile := filepath.Join(dir, "prices.yaml")
+	// Old price list without magmatic gas.
+	err := os.WriteFile(priceFile, []byte("4051: 20000\n4246: 21000\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(zap.NewNop().Sugar(), NewStaticProvider(priceFile), NewFileStorage(filepath.Join(dir, "prices.bin")), time.Hour)
+	err = cache.Refresh([]int32{4051, 4246, 81143})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := cache.Snapshot().Prices
+	if prices[4051] != 20000 || prices[4246] != 21000 {
+		t.Errorf("expected fuel block prices, got: %v", prices)
+	}
+	if _, ok := prices[81143]; ok {
+		t.Errorf("expected no magmatic gas price, got: %v", prices)
+	}
+	refreshed := cache.Snapshot().UpdatedAt
+
+	// Price list without hydrogen, its last known price is kept with
+	// the time it was loaded.
+	err = os.WriteFile(priceFile, []byte("4051: 22000\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cache.Refresh([]int32{4051, 4246})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := cache.Snapshot()
+	if snapshot.Prices[4051] != 22000 || snapshot.Prices[4246] != 21000 {
+		t.Errorf("expected new nitrogen and last known hydrogen price, got: %v", snapshot.Prices)
+	}
+	if !snapshot.UpdatedAt.Equal(refreshed) || !snapshot.Updated[4246].Equal(refreshed) {
+		t.Errorf("expected carried over price to keep its time %s, got: %+v", refreshed, snapshot)
+	}
+	if !snapshot.Updated[4051].After(refreshed) {
+		t.Errorf("expected new nitrogen price time, got: %+v", snapshot)
+	}
+}
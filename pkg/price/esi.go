@@ -0,0 +1,105 @@
+package price
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/lunemec/eve-fuelbot/pkg/esipage"
+
+	"github.com/antihax/goesi"
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/pkg/errors"
+)
+
+type esiProvider struct {
+	esi        *goesi.APIClient
+	regionID   int32
+	stationID  int64
+	percentile float64
+}
+
+// NewESIProvider returns provider computing prices from ESI regional
+// sell orders. Price is volume weighted average of the cheapest
+// percentile of sell volume. When stationID is not 0, only orders in
+// that station are used.
+func NewESIProvider(client *http.Client, regionID int32, stationID int64, percentile float64) Provider {
+	return &esiProvider{
+		esi:        goesi.NewAPIClient(client, "EVE FuelBot"),
+		regionID:   regionID,
+		stationID:  stationID,
+		percentile: percentile,
+	}
+}
+
+func (p *esiProvider) Prices(ctx context.Context, typeIDs []int32) (map[int32]float64, error) {
+	out := make(map[int32]float64)
+	for _, typeID := range typeIDs {
+		orders, err := p.sellOrders(ctx, typeID)
+		if err != nil {
+			return nil, err
+		}
+		if len(orders) == 0 {
+			// No sell orders for type in region.
+			continue
+		}
+		out[typeID] = percentilePrice(orders, p.percentile)
+	}
+	return out, nil
+}
+
+func (p *esiProvider) sellOrders(ctx context.Context, typeID int32) ([]esi.GetMarketsRegionIdOrders200Ok, error) {
+	var (
+		out   []esi.GetMarketsRegionIdOrders200Ok
+		page  int32 = 1
+		pages int32 = 1
+	)
+	for ; page <= pages; page++ {
+		orders, resp, err := p.esi.ESI.MarketApi.GetMarketsRegionIdOrders(ctx, "sell", p.regionID, &esi.GetMarketsRegionIdOrdersOpts{
+			TypeId: optional.NewInt32(typeID),
+			Page:   optional.NewInt32(page),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read market orders for type: %d", typeID)
+		}
+		pages = esipage.Count(resp)
+		for _, order := range orders {
+			if p.stationID != 0 && order.LocationId != p.stationID {
+				continue
+			}
+			out = append(out, order)
+		}
+	}
+	return out, nil
+}
+
+// percentilePrice returns volume weighted average price of the cheapest
+// percentile of total volume.
+func percentilePrice(orders []esi.GetMarketsRegionIdOrders200Ok, percentile float64) float64 {
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Price < orders[j].Price
+	})
+	var totalVolume float64
+	for _, order := range orders {
+		totalVolume += float64(order.VolumeRemain)
+	}
+	wantVolume := totalVolume * percentile / 100
+
+	var volume, value float64
+	for _, order := range orders {
+		orderVolume := float64(order.VolumeRemain)
+		if volume+orderVolume > wantVolume {
+			orderVolume = wantVolume - volume
+		}
+		volume += orderVolume
+		value += orderVolume * order.Price
+		if volume >= wantVolume {
+			break
+		}
+	}
+	if volume == 0 {
+		return orders[0].Price
+	}
+	return value / volume
+}
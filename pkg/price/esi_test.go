@@ -0,0 +1,32 @@
+package price
+
+import (
+	"testing"
+
+	"github.com/antihax/goesi/esi"
+)
+
+func TestPercentilePrice(t *testing.T) {
+	orders := []esi.GetMarketsRegionIdOrders200Ok{
+		{Price: 30, VolumeRemain: 100},
+		{Price: 10, VolumeRemain: 50},
+		{Price: 20, VolumeRemain: 50},
+	}
+
+	tests := []struct {
+		percentile float64
+		price      float64
+	}{
+		// 5% of 200 = 10 units, all from the cheapest order.
+		{percentile: 5, price: 10},
+		// 50% of 200 = 100 units, 50 for 10 and 50 for 20.
+		{percentile: 50, price: 15},
+		{percentile: 100, price: 22.5},
+	}
+	for _, test := range tests {
+		price := percentilePrice(orders, test.percentile)
+		if price != test.price {
+			t.Errorf("percentilePrice(%.0f) = %f, expected: %f", test.percentile, price, test.price)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FuzzworkAggregatesURL is Fuzzwork market aggregates API.
+const FuzzworkAggregatesURL = "https://market.fuzzwork.co.uk/aggregates/"
+
+type fuzzworkProvider struct {
+	client    *http.Client
+	url       string
+	regionID  int32
+	stationID int64
+}
+
+type fuzzworkAggregate struct {
+	Buy  fuzzworkStats `json:"buy"`
+	Sell fuzzworkStats `json:"sell"`
+}
+
+// Fuzzwork returns all numbers as strings.
+type fuzzworkStats struct {
+	WeightedAverage string `json:"weightedAverage"`
+	Max             string `json:"max"`
+	Min             string `json:"min"`
+	StdDev          string `json:"stddev"`
+	Median          string `json:"median"`
+	Volume          string `json:"volume"`
+	OrderCount      string `json:"orderCount"`
+	Percentile      string `json:"percentile"`
+}
+
+// NewFuzzworkProvider returns provider using Fuzzwork style aggregates
+// API at aggregatesURL. Price is sell 5th percentile of the station,
+// or the whole region when stationID is 0.
+func NewFuzzworkProvider(client *http.Client, aggregatesURL string, regionID int32, stationID int64) Provider {
+	return &fuzzworkProvider{
+		client:    client,
+		url:       aggregatesURL,
+		regionID:  regionID,
+		stationID: stationID,
+	}
+}
+
+func (p *fuzzworkProvider) Prices(ctx context.Context, typeIDs []int32) (map[int32]float64, error) {
+	aggregatesURL, err := url.Parse(p.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing fuzzwork api url")
+	}
+	query := aggregatesURL.Query()
+	if p.stationID != 0 {
+		query.Set("station", fmt.Sprint(p.stationID))
+	} else {
+		query.Set("region", fmt.Sprint(p.regionID))
+	}
+	var types []string
+	for _, typeID := range typeIDs {
+		types = append(types, fmt.Sprint(typeID))
+	}
+	query.Set("types", strings.Join(types, ","))
+	aggregatesURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, aggregatesURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating fuzzwork api request")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error calling fuzzwork api")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fuzzwork api returned status: %s", resp.Status)
+	}
+
+	var aggregates map[string]fuzzworkAggregate
+	err = json.NewDecoder(resp.Body).Decode(&aggregates)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing fuzzwork json")
+	}
+
+	out := make(map[int32]float64)
+	for _, typeID := range typeIDs {
+		aggregate, ok := aggregates[fmt.Sprint(typeID)]
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(aggregate.Sell.Percentile, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing fuzzwork price for type: %d", typeID)
+		}
+		out[typeID] = price
+	}
+	return out, nil
+}
@@ -0,0 +1,19 @@
+package price
+
+import (
+	"context"
+)
+
+// Provider is interface for market price sources.
+type Provider interface {
+	// Prices returns price per unit for each of typeIDs, types without
+	// price are missing from the map.
+	Prices(ctx context.Context, typeIDs []int32) (map[int32]float64, error)
+}
+
+const (
+	// TheForgeRegionID is The Forge region (Jita).
+	TheForgeRegionID = 10000002
+	// JitaStationID is Jita IV - Moon 4 - Caldari Navy Assembly Plant.
+	JitaStationID = 60003760
+)
@@ -0,0 +1,48 @@
+package price
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type staticProvider struct {
+	filename string
+}
+
+// NewStaticProvider returns provider reading prices from YAML (or JSON)
+// file mapping type ID to price, eg.:
+//
+//	4051: 1500.5 # Nitrogen Fuel Block
+//	4246: 1450
+//
+// The file is read on every call, so it can be edited while running.
+func NewStaticProvider(filename string) Provider {
+	return &staticProvider{
+		filename: filename,
+	}
+}
+
+func (p *staticProvider) Prices(ctx context.Context, typeIDs []int32) (map[int32]float64, error) {
+	data, err := os.ReadFile(p.filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read price file: %s", p.filename)
+	}
+	var prices map[int32]float64
+	err = yaml.Unmarshal(data, &prices)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing price file: %s", p.filename)
+	}
+
+	out := make(map[int32]float64)
+	for _, typeID := range typeIDs {
+		price, ok := prices[typeID]
+		if !ok {
+			continue
+		}
+		out[typeID] = price
+	}
+	return out, nil
+}
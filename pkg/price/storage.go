@@ -0,0 +1,67 @@
+package price
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Storage is interface for persisting price snapshots.
+type Storage interface {
+	Read() (Snapshot, error)
+	Write(Snapshot) error
+}
+
+type fileStorage struct {
+	filename string
+}
+
+// NewFileStorage returns price storage in file.
+func NewFileStorage(filename string) Storage {
+	return &fileStorage{
+		filename: filename,
+	}
+}
+
+// Read returns empty Snapshot when the file does not exist yet.
+func (fs *fileStorage) Read() (Snapshot, error) {
+	var out Snapshot
+	f, err := os.Open(fs.filename)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return out, errors.Wrapf(err, "unable to open file for reading: %s", fs.filename)
+	}
+	defer f.Close()
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&out)
+	if err != nil {
+		return Snapshot{}, errors.Wrap(err, "error decoding price file")
+	}
+	return out, nil
+}
+
+// Write replaces the file with supplied snapshot. The snapshot is
+// written to a temporary file first and renamed, so a crash mid-write
+// does not leave a corrupted file behind.
+func (fs *fileStorage) Write(snapshot Snapshot) error {
+	f, err := os.CreateTemp(filepath.Dir(fs.filename), filepath.Base(fs.filename)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temporary file for: %s", fs.filename)
+	}
+	defer os.Remove(f.Name())
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(snapshot)
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "error encoding price file")
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file: %s", f.Name())
+	}
+	return errors.Wrapf(os.Rename(f.Name(), fs.filename), "unable to replace file: %s", fs.filename)
+}
@@ -0,0 +1,36 @@
+package price
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewFileStorage(filepath.Join(dir, "prices.bin"))
+
+	err := storage.Write(Snapshot{Prices: map[int32]float64{4051: 20000}})
+	if err != nil {
+		t.Fatalf("unable to write prices: %v", err)
+	}
+	err = storage.Write(Snapshot{Prices: map[int32]float64{4051: 21000}})
+	if err != nil {
+		t.Fatalf("unable to replace prices: %v", err)
+	}
+	snapshot, err := storage.Read()
+	if err != nil {
+		t.Fatalf("unable to read prices: %v", err)
+	}
+	if snapshot.Prices[4051] != 21000 {
+		t.Errorf("expected replaced price, got: %v", snapshot.Prices)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected temporary files to be removed, got %d files", len(files))
+	}
+}
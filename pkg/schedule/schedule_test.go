@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Monday.
+	now := time.Date(2024, 1, 15, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{spec: "0 9 * * *", next: time.Date(2024, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", next: time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 9 * * fri", next: time.Date(2024, 1, 19, 9, 0, 0, 0, time.UTC)},
+		{spec: "0 9 * * 7", next: time.Date(2024, 1, 21, 9, 0, 0, 0, time.UTC)},
+		{spec: "0 18 1,15 * *", next: time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", next: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 feb *", next: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day of month or week matches when both are set.
+		{spec: "0 0 20 * mon", next: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", next: time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", test.spec, err)
+		}
+		if next := s.Next(now); !next.Equal(test.next) {
+			t.Errorf("Parse(%q).Next() = %s, expected %s", test.spec, next, test.next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * mo", "5-1 * * * *", "*/0 * * * *"} {
+		_, err := Parse(spec)
+		if err == nil {
+			t.Errorf("Parse(%q) expected error", spec)
+		}
+	}
+}
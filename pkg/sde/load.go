@@ -0,0 +1,417 @@
+package sde
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	structureCategoryID       = 65  // "Structure"
+	structureModuleCategoryID = 66  // "Structure Module"
+	controlTowerGroupID       = 365 // "Control Tower"
+
+	strontiumTypeID = 16275 // "Strontium Clathrates"
+
+	// Control tower resource purposes.
+	resourcePurposeOnline    = 1
+	resourcePurposeReinforce = 4
+
+	// serviceModuleFuelAttribute is dogma attribute with service module
+	// fuel consumption per hour.
+	serviceModuleFuelAttribute = "serviceModuleFuelAmount"
+	// fuelBayCapacityAttribute is dogma attribute with structure fuel
+	// bay capacity in m3.
+	fuelBayCapacityAttribute = "specialFuelBayCapacity"
+	// requiredSkillAttribute and canFitGroupAttribute are prefixes of
+	// numbered dogma attributes, eg. "requiredSkill1" or
+	// "canFitShipGroup01".
+	requiredSkillAttribute = "requiredSkill"
+	canFitGroupAttribute   = "canFitShipGroup"
+
+	// Dogma modifier functions selecting modules a bonus applies to.
+	modifierRequiredSkill = "LocationRequiredSkillModifier"
+	modifierGroup         = "LocationGroupModifier"
+
+	// Dogma modifier operations.
+	operationPostMul     = 4
+	operationPostPercent = 6
+)
+
+// structureGas maps structure names to magmatic gas they burn per hour,
+// SDE has no dogma attribute for it.
+var structureGas = map[string]uint32{
+	"Metenox Moon Drill": 200,
+}
+
+type moduleService struct {
+	Category string
+	// Service name as reported by ESI.
+	Service string
+}
+
+// moduleServices maps service module names to the service they provide.
+// ESI reports services by their name, not by the module type.
+var moduleServices = map[string]moduleService{
+	"Standup Cloning Center I":        {Category: "citadel", Service: "Clone Bay"},
+	"Standup Market Hub I":            {Category: "citadel", Service: "Market"},
+	"Standup Manufacturing Plant I":   {Category: "engineering", Service: "Manufacturing (Standard)"},
+	"Standup Capital Shipyard I":      {Category: "engineering", Service: "Manufacturing (Capital)"},
+	"Standup Supercapital Shipyard I": {Category: "engineering", Service: "Manufacturing (Supercapital)"},
+	// Research lab provides copying, ME and TE research, fuel is only
+	// counted once.
+	"Standup Research Lab I":          {Category: "engineering", Service: "Blueprint Copying"},
+	"Standup Invention Lab I":         {Category: "engineering", Service: "Invention"},
+	"Standup Reprocessing Facility I": {Category: "reprocessing", Service: "Reprocessing"},
+	"Standup Moon Drill I":            {Category: "resource processing", Service: "Moon Drilling"},
+	"Standup Biochemical Reactor I":   {Category: "reaction", Service: "Biochemical Reactions"},
+	"Standup Composite Reactor I":     {Category: "reaction", Service: "Composite Reactions"},
+	"Standup Hybrid Reactor I":        {Category: "reaction", Service: "Hybrid Reactions"},
+}
+
+type sdeType struct {
+	GroupID   int32             `yaml:"groupID"`
+	Name      map[string]string `yaml:"name"`
+	Published bool              `yaml:"published"`
+}
+
+type sdeGroup struct {
+	CategoryID int32             `yaml:"categoryID"`
+	Name       map[string]string `yaml:"name"`
+}
+
+type sdeTypeDogma struct {
+	DogmaAttributes []struct {
+		AttributeID int32   `yaml:"attributeID"`
+		Value       float64 `yaml:"value"`
+	} `yaml:"dogmaAttributes"`
+	DogmaEffects []struct {
+		EffectID int32 `yaml:"effectID"`
+	} `yaml:"dogmaEffects"`
+}
+
+type sdeDogmaEffect struct {
+	ModifierInfo []sdeModifier `yaml:"modifierInfo"`
+}
+
+type sdeModifier struct {
+	Func                 string `yaml:"func"`
+	ModifiedAttributeID  int32  `yaml:"modifiedAttributeID"`
+	ModifyingAttributeID int32  `yaml:"modifyingAttributeID"`
+	Operation            int32  `yaml:"operation"`
+	SkillTypeID          int32  `yaml:"skillTypeID"`
+	GroupID              int32  `yaml:"groupID"`
+}
+
+type sdeControlTowerResources struct {
+	Resources []struct {
+		Purpose        int32 `yaml:"purpose"`
+		Quantity       int32 `yaml:"quantity"`
+		ResourceTypeID int32 `yaml:"resourceTypeID"`
+		// FactionID is set for charters needed only in empire space.
+		FactionID int32 `yaml:"factionID"`
+	} `yaml:"resources"`
+}
+
+type sdeDogmaAttribute struct {
+	Name string `yaml:"name"`
+}
+
+// Load builds data from unpacked SDE (Static Data Export) in dir. Dir
+// is the SDE root, or its "fsd" directory.
+func Load(dir string) (*Data, error) {
+	fsd := filepath.Join(dir, "fsd")
+	if _, err := os.Stat(fsd); err != nil {
+		fsd = dir
+	}
+
+	var (
+		types      map[int32]sdeType
+		groups     map[int32]sdeGroup
+		typeDogma  map[int32]sdeTypeDogma
+		attributes map[int32]sdeDogmaAttribute
+		effects    map[int32]sdeDogmaEffect
+		towers     map[int32]sdeControlTowerResources
+	)
+	files := []struct {
+		name string
+		out  interface{}
+	}{
+		{name: "typeIDs.yaml", out: &types},
+		{name: "groupIDs.yaml", out: &groups},
+		{name: "typeDogma.yaml", out: &typeDogma},
+		{name: "dogmaAttributes.yaml", out: &attributes},
+		{name: "dogmaEffects.yaml", out: &effects},
+	}
+	for _, file := range files {
+		err := readYAML(filepath.Join(fsd, file.name), file.out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Control tower resources are missing in older SDE dumps, POS fuel
+	// is unknown then.
+	err := readYAML(filepath.Join(fsd, "controlTowerResources.yaml"), &towers)
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+
+	d := dogma{
+		types:      typeDogma,
+		effects:    effects,
+		attributes: make(map[string]int32),
+	}
+	for id, attribute := range attributes {
+		d.attributes[attribute.Name] = id
+	}
+	fuelAttributeID, ok := d.attributes[serviceModuleFuelAttribute]
+	if !ok {
+		return nil, errors.Errorf("dogma attribute %s not found", serviceModuleFuelAttribute)
+	}
+
+	// Service modules first, structure bonuses are resolved to service
+	// categories through them.
+	var (
+		modules []module
+		other   []module
+	)
+	for typeID, t := range types {
+		if !t.Published || groups[t.GroupID].CategoryID != structureModuleCategoryID {
+			continue
+		}
+		m := module{
+			typeID:  typeID,
+			name:    t.Name["en"],
+			groupID: t.GroupID,
+			skills:  d.numbered(typeID, requiredSkillAttribute),
+			fitsTo:  d.numbered(typeID, canFitGroupAttribute),
+			fuel:    d.attribute(typeID, fuelAttributeID),
+		}
+		service, ok := moduleServices[m.name]
+		if !ok {
+			other = append(other, m)
+			continue
+		}
+		m.category = service.Category
+		modules = append(modules, m)
+	}
+	serviceGroups := make(map[int32]bool)
+	for _, m := range modules {
+		for _, groupID := range m.fitsTo {
+			serviceGroups[groupID] = true
+		}
+	}
+
+	data := &Data{
+		Structures: make(map[int32]Structure),
+		Services:   make(map[string][]Service),
+		Towers:     make(map[int32]Tower),
+	}
+	for _, m := range modules {
+		service := moduleServices[m.name]
+		data.Services[m.category] = append(data.Services[m.category], Service{
+			Name:        service.Service,
+			FuelPerHour: uint32(m.fuel),
+		})
+	}
+	for _, m := range other {
+		effects := d.fuelEffects(m.typeID, fuelAttributeID, modules)
+		if len(effects) == 0 {
+			continue
+		}
+		if data.Rigs == nil {
+			data.Rigs = make(map[int32]Rig)
+		}
+		data.Rigs[m.typeID] = Rig{Name: m.name, Effects: effects}
+	}
+
+	for typeID, t := range types {
+		if !t.Published {
+			continue
+		}
+		group := groups[t.GroupID]
+		name := t.Name["en"]
+		if t.GroupID == controlTowerGroupID {
+			if tower, ok := controlTower(name, towers[typeID]); ok {
+				data.Towers[typeID] = tower
+			}
+			continue
+		}
+		if group.CategoryID != structureCategoryID {
+			continue
+		}
+		// Fuel burned by the structure itself, eg. Metenox moon drill.
+		fuelPerHour := d.attribute(typeID, fuelAttributeID)
+		if !serviceGroups[t.GroupID] {
+			// FLEX structures cannot fit any regular service module, their
+			// service is built in.
+			fuelPerHour += builtInFuel(t.GroupID, other)
+		}
+		data.Structures[typeID] = Structure{
+			Name:        name,
+			Group:       group.Name["en"],
+			Effects:     d.fuelEffects(typeID, fuelAttributeID, modules),
+			FuelBay:     d.attribute(typeID, d.attributes[fuelBayCapacityAttribute]),
+			FuelPerHour: uint32(fuelPerHour),
+			GasPerHour:  structureGas[name],
+		}
+	}
+	if len(data.Structures) == 0 {
+		return nil, errors.Errorf("no structures found in sde: %s", dir)
+	}
+	for _, services := range data.Services {
+		sort.Slice(services, func(i, j int) bool {
+			return services[i].Name < services[j].Name
+		})
+	}
+	return data, nil
+}
+
+// module is structure module with attributes needed to find which
+// bonuses apply to it.
+type module struct {
+	typeID  int32
+	name    string
+	groupID int32
+	// category of service the module provides, empty for other modules.
+	category string
+	skills   []int32
+	fitsTo   []int32
+	fuel     float64
+}
+
+// builtInFuel returns fuel of service module which fits only structures
+// of group, 0 when there is none.
+func builtInFuel(groupID int32, modules []module) float64 {
+	var fuel float64
+	for _, m := range modules {
+		if len(m.fitsTo) == 1 && m.fitsTo[0] == groupID && m.fuel > fuel {
+			fuel = m.fuel
+		}
+	}
+	return fuel
+}
+
+// dogma holds SDE dogma of all types.
+type dogma struct {
+	types   map[int32]sdeTypeDogma
+	effects map[int32]sdeDogmaEffect
+	// attributes are attribute IDs by name.
+	attributes map[string]int32
+}
+
+// attribute returns value of type dogma attribute, 0 when the type
+// does not have it.
+func (d dogma) attribute(typeID, attributeID int32) float64 {
+	for _, attribute := range d.types[typeID].DogmaAttributes {
+		if attribute.AttributeID == attributeID {
+			return attribute.Value
+		}
+	}
+	return 0
+}
+
+// numbered returns non-zero values of numbered attributes with prefix,
+// eg. all "requiredSkill1" to "requiredSkill6", as IDs.
+func (d dogma) numbered(typeID int32, prefix string) []int32 {
+	var out []int32
+	for name, attributeID := range d.attributes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, prefix)); err != nil {
+			continue
+		}
+		if value := d.attribute(typeID, attributeID); value != 0 {
+			out = append(out, int32(value))
+		}
+	}
+	return out
+}
+
+// fuelEffects returns service fuel multipliers of type's dogma effects,
+// by service category of modules each modifier applies to.
+func (d dogma) fuelEffects(typeID, fuelAttributeID int32, modules []module) []Effect {
+	multipliers := make(map[string]float64)
+	for _, typeEffect := range d.types[typeID].DogmaEffects {
+		for _, modifier := range d.effects[typeEffect.EffectID].ModifierInfo {
+			if modifier.ModifiedAttributeID != fuelAttributeID {
+				continue
+			}
+			value := d.attribute(typeID, modifier.ModifyingAttributeID)
+			var multiplier float64
+			switch modifier.Operation {
+			case operationPostPercent:
+				multiplier = 1 + value/100
+			case operationPostMul:
+				multiplier = value
+			default:
+				continue
+			}
+			for _, m := range modules {
+				if !modifier.applies(m) {
+					continue
+				}
+				if _, ok := multipliers[m.category]; !ok {
+					multipliers[m.category] = 1
+				}
+				multipliers[m.category] *= multiplier
+			}
+		}
+	}
+
+	var out []Effect
+	for category, multiplier := range multipliers {
+		out = append(out, Effect{Category: category, Multiplier: multiplier})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Category < out[j].Category
+	})
+	return out
+}
+
+// applies checks if modifier selects module.
+func (m sdeModifier) applies(mod module) bool {
+	switch m.Func {
+	case modifierRequiredSkill:
+		for _, skill := range mod.skills {
+			if skill == m.SkillTypeID {
+				return true
+			}
+		}
+	case modifierGroup:
+		return mod.groupID == m.GroupID
+	}
+	return false
+}
+
+// controlTower returns tower with its fuel block and strontium
+// consumption, false when it has no fuel blocks.
+func controlTower(name string, resources sdeControlTowerResources) (Tower, bool) {
+	tower := Tower{Name: name}
+	for _, resource := range resources.Resources {
+		switch {
+		case resource.Purpose == resourcePurposeOnline && resource.FactionID == 0:
+			tower.FuelTypeID = resource.ResourceTypeID
+			tower.FuelPerHour = uint32(resource.Quantity)
+		case resource.Purpose == resourcePurposeReinforce && resource.ResourceTypeID == strontiumTypeID:
+			tower.StrontiumPerHour = uint32(resource.Quantity)
+		}
+	}
+	return tower, tower.FuelTypeID != 0
+}
+
+func readYAML(filename string, out interface{}) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open sde file: %s", filename)
+	}
+	defer f.Close()
+	return errors.Wrapf(yaml.NewDecoder(f).Decode(out), "error parsing sde file: %s", filename)
+}
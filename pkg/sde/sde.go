@@ -0,0 +1,105 @@
+package sde
+
+import (
+	_ "embed" // Bundled snapshot.
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Data is structure and service fuel information needed by the bot.
+type Data struct {
+	// Structures by type ID.
+	Structures map[int32]Structure `yaml:"structures"`
+	// Services by service category, eg. "citadel" or "engineering".
+	Services map[string][]Service `yaml:"services"`
+	// Towers are POS control towers by type ID.
+	Towers map[int32]Tower `yaml:"towers,omitempty"`
+	// Rigs changing service fuel by type ID.
+	Rigs map[int32]Rig `yaml:"rigs,omitempty"`
+}
+
+// Structure type and its service fuel bonuses.
+type Structure struct {
+	Name    string   `yaml:"name"`
+	Group   string   `yaml:"group,omitempty"`
+	Effects []Effect `yaml:"effects,omitempty"`
+	// FuelBay capacity in m3, 0 when unknown.
+	FuelBay float64 `yaml:"fuel_bay,omitempty"`
+	// FuelPerHour is fuel burned regardless of services.
+	FuelPerHour uint32 `yaml:"fuel_per_hour,omitempty"`
+	// GasPerHour is magmatic gas burned by Metenox moon drill.
+	GasPerHour uint32 `yaml:"gas_per_hour,omitempty"`
+}
+
+// Rig is structure rig and its service fuel bonuses.
+type Rig struct {
+	Name    string   `yaml:"name"`
+	Effects []Effect `yaml:"effects"`
+}
+
+// Effect multiplies fuel of all services in the category.
+type Effect struct {
+	Category   string  `yaml:"category"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// Service as named by ESI and its base fuel consumption.
+type Service struct {
+	Name        string `yaml:"name"`
+	FuelPerHour uint32 `yaml:"fuel_per_hour"`
+}
+
+// Tower is POS control tower and its fuel consumption.
+type Tower struct {
+	Name string `yaml:"name"`
+	// FuelTypeID is type ID of fuel block the tower burns.
+	FuelTypeID  int32  `yaml:"fuel_type_id"`
+	FuelPerHour uint32 `yaml:"fuel_per_hour"`
+	// StrontiumPerHour is strontium consumption while reinforced.
+	StrontiumPerHour uint32 `yaml:"strontium_per_hour"`
+}
+
+//go:embed snapshot.yaml
+var snapshot []byte
+
+// Default returns data from snapshot bundled with the bot.
+func Default() (*Data, error) {
+	return parse(snapshot)
+}
+
+// ReadFile reads data snapshot written by WriteFile.
+func ReadFile(filename string) (*Data, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read sde snapshot: %s", filename)
+	}
+	return parse(data)
+}
+
+// WriteFile writes data snapshot to file.
+func WriteFile(filename string, data *Data) error {
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "error encoding sde snapshot")
+	}
+	out = append([]byte(snapshotHeader), out...)
+	return errors.Wrapf(os.WriteFile(filename, out, 0644), "unable to write sde snapshot: %s", filename)
+}
+
+const snapshotHeader = `# Structure and service fuel data for EVE FuelBot.
+# Refresh with: fuelbot sde --sde_dir <unpacked SDE> --out pkg/sde/snapshot.yaml
+`
+
+func parse(in []byte) (*Data, error) {
+	var data Data
+	err := yaml.Unmarshal(in, &data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing sde snapshot")
+	}
+	if len(data.Structures) == 0 {
+		return nil, errors.New("sde snapshot contains no structures")
+	}
+	return &data, nil
+}
@@ -0,0 +1,94 @@
+package sde
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	data, err := Load("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStructures := map[int32]Structure{
+		35832: {
+			Name:    "Astrahus",
+			Group:   "Citadel",
+			Effects: []Effect{{Category: "citadel", Multiplier: 0.75}},
+			FuelBay: 8000,
+		},
+		35835: {
+			Name:  "Athanor",
+			Group: "Refinery",
+			Effects: []Effect{
+				{Category: "reprocessing", Multiplier: 0.8},
+			},
+		},
+		35841: {
+			Name:        "Ansiblex Jump Gate",
+			Group:       "Jump Bridge",
+			FuelPerHour: 30,
+		},
+	}
+	if !reflect.DeepEqual(data.Structures, expectedStructures) {
+		t.Errorf("unexpected structures: %+v", data.Structures)
+	}
+	expectedServices := map[string][]Service{
+		"citadel":      {{Name: "Market", FuelPerHour: 40}},
+		"reprocessing": {{Name: "Reprocessing", FuelPerHour: 10}},
+	}
+	if !reflect.DeepEqual(data.Services, expectedServices) {
+		t.Errorf("unexpected services: %+v", data.Services)
+	}
+	expectedRigs := map[int32]Rig{
+		37000: {
+			Name:    "Standup M-Set Reprocessing Fuel Rig",
+			Effects: []Effect{{Category: "reprocessing", Multiplier: 0.9}},
+		},
+	}
+	if !reflect.DeepEqual(data.Rigs, expectedRigs) {
+		t.Errorf("unexpected rigs: %+v", data.Rigs)
+	}
+	expectedTowers := map[int32]Tower{
+		12235: {Name: "Amarr Control Tower", FuelTypeID: 4247, FuelPerHour: 40, StrontiumPerHour: 400},
+	}
+	if !reflect.DeepEqual(data.Towers, expectedTowers) {
+		t.Errorf("unexpected towers: %+v", data.Towers)
+	}
+
+	filename := filepath.Join(t.TempDir(), "snapshot.yaml")
+	err = WriteFile(filename, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, data) {
+		t.Errorf("snapshot differs after write and read: %+v", read)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	data, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Structures[35832].Name != "Astrahus" {
+		t.Errorf("bundled snapshot is missing Astrahus: %+v", data.Structures[35832])
+	}
+	if data.Structures[81826].GasPerHour == 0 {
+		t.Errorf("bundled snapshot is missing Metenox gas consumption: %+v", data.Structures[81826])
+	}
+	if data.Structures[35832].FuelBay != 8000 {
+		t.Errorf("bundled snapshot is missing Astrahus fuel bay: %+v", data.Structures[35832])
+	}
+	for typeID, structure := range data.Structures {
+		if structure.FuelBay == 0 {
+			t.Errorf("bundled snapshot is missing fuel bay of %d: %+v", typeID, structure)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package state
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// State is everything the bot needs to remember between restarts.
+type State struct {
+	// Notified holds the last fuel notification sent for each structure.
+	Notified map[int64]Notification
+	// States holds the last seen state of each structure.
+	States map[int64]string
+	// LowPowerSince holds when each structure was first seen without fuel.
+	LowPowerSince map[int64]time.Time
+	// FuelExpires holds fuel expiration of each structure from the last check.
+	FuelExpires map[int64]time.Time
+	// FuelBlocks holds fuel blocks of each starbase, and in fuel bay of each
+	// structure, from the last check.
+	FuelBlocks map[int64]int64
+	// StockNotified holds the last low fuel stock notification sent for
+	// each solar system or region.
+	StockNotified map[int64]Notification
+	// GasNotified holds the last magmatic gas notification sent for each
+	// Metenox moon drill.
+	GasNotified map[int64]Notification
+	// OzoneNotified holds the last low liquid ozone notification sent for
+	// each jump gate.
+	OzoneNotified map[int64]Notification
+	// StrontiumNotified holds the last low strontium notification sent for
+	// each starbase.
+	StrontiumNotified map[int64]Notification
+	// ExtractionNotified holds chunk arrival of the last moon extraction
+	// notified for each structure.
+	ExtractionNotified map[int64]time.Time
+	// SeenNotifications holds timestamp of each forwarded in-game
+	// notification by its ID.
+	SeenNotifications map[int64]time.Time
+	// Escalations holds escalation of unresolved fuel alert of each
+	// structure, until it is refuelled.
+	Escalations map[int64]Escalation
+	// Acks holds claim, snooze or ignore of alerts of each structure.
+	Acks map[int64]Ack
+	// Boards holds status board message IDs in each channel.
+	Boards map[string][]string
+	// DigestBands holds fuel colour band of each structure and starbase
+	// at the last digest.
+	DigestBands map[int64]string
+	// Consumption holds fuel blocks per day of all structures by date.
+	Consumption map[string]float64
+}
+
+// Notification records when a notification was sent.
+type Notification struct {
+	At time.Time
+	// Threshold is how long before running out of fuel the threshold
+	// the notification was sent for applies.
+	Threshold time.Duration
+}
+
+// Escalation records when the first fuel alert was sent and how many
+// escalation steps were sent since.
+type Escalation struct {
+	Since time.Time
+	Level int
+}
+
+// Ack records who acknowledged alerts of a structure and until when they
+// are suppressed, zero Until suppresses them until removed.
+type Ack struct {
+	Action string
+	By     string
+	At     time.Time
+	Until  time.Time
+}
+
+// New returns empty initialized State.
+func New() State {
+	return State{
+		Notified:           make(map[int64]Notification),
+		States:             make(map[int64]string),
+		LowPowerSince:      make(map[int64]time.Time),
+		FuelExpires:        make(map[int64]time.Time),
+		FuelBlocks:         make(map[int64]int64),
+		StockNotified:      make(map[int64]Notification),
+		GasNotified:        make(map[int64]Notification),
+		OzoneNotified:      make(map[int64]Notification),
+		StrontiumNotified:  make(map[int64]Notification),
+		ExtractionNotified: make(map[int64]time.Time),
+		SeenNotifications:  make(map[int64]time.Time),
+		Escalations:        make(map[int64]Escalation),
+		Acks:               make(map[int64]Ack),
+		Boards:             make(map[string][]string),
+		DigestBands:        make(map[int64]string),
+		Consumption:        make(map[string]float64),
+	}
+}
+
+// Storage is interface for accessing bot state.
+type Storage interface {
+	Read() (State, error)
+	Write(State) error
+}
+
+type fileStorage struct {
+	filename string
+}
+
+// NewFileStorage returns state storage in file.
+func NewFileStorage(filename string) Storage {
+	return &fileStorage{
+		filename: filename,
+	}
+}
+
+// Read returns empty State when the file does not exist yet.
+func (fs *fileStorage) Read() (State, error) {
+	out := New()
+	f, err := os.Open(fs.filename)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return out, errors.Wrapf(err, "unable to open file for reading: %s", fs.filename)
+	}
+	defer f.Close()
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&out)
+	if err != nil {
+		return New(), errors.Wrap(err, "error decoding state file")
+	}
+	return out, nil
+}
+
+// Write replaces the file with supplied state. The state is written to
+// a temporary file first and renamed, so a crash mid-write does not
+// leave a corrupted file behind.
+func (fs *fileStorage) Write(state State) error {
+	f, err := os.CreateTemp(filepath.Dir(fs.filename), filepath.Base(fs.filename)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temporary file for: %s", fs.filename)
+	}
+	defer os.Remove(f.Name())
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(state)
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "error encoding state file")
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file: %s", f.Name())
+	}
+	return errors.Wrapf(os.Rename(f.Name(), fs.filename), "unable to replace file: %s", fs.filename)
+}
+
+type memoryStorage struct {
+	state State
+}
+
+// NewMemoryStorage returns state storage which is lost on restart.
+func NewMemoryStorage() Storage {
+	return &memoryStorage{
+		state: New(),
+	}
+}
+
+func (ms *memoryStorage) Read() (State, error) {
+	return ms.state, nil
+}
+
+func (ms *memoryStorage) Write(state State) error {
+	ms.state = state
+	return nil
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorage(t *testing.T) {
+	storage := NewFileStorage(filepath.Join(t.TempDir(), "state.bin"))
+
+	st, err := storage.Read()
+	if err != nil {
+		t.Fatalf("reading missing file should return empty state: %v", err)
+	}
+	if len(st.Notified) != 0 {
+		t.Fatalf("expected empty state, got: %+v", st)
+	}
+
+	at := time.Date(2021, 5, 11, 0, 0, 0, 0, time.UTC)
+	st.Notified[1337] = Notification{At: at}
+	err = storage.Write(st)
+	if err != nil {
+		t.Fatalf("unable to write state: %v", err)
+	}
+
+	st, err = storage.Read()
+	if err != nil {
+		t.Fatalf("unable to read state: %v", err)
+	}
+	if !st.Notified[1337].At.Equal(at) {
+		t.Fatalf("expected notified at %s, got: %+v", at, st.Notified)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 // Source is interface for token source.
 type Source interface {
+	Name() string
 	Token() (*oauth2.Token, error)
 	TokenSource() (oauth2.TokenSource, error)
 	Verify() (*goesi.VerifyResponse, error)
@@ -21,19 +22,42 @@ type logger interface {
 }
 
 type source struct {
+	name    string
 	sso     *goesi.SSOAuthenticator
 	storage Storage
 }
 
-// NewSource returns new token source from storage.
-func NewSource(log logger, client *http.Client, storage Storage, secretKey []byte, clientID, ssoSecret string, callbackURL string, scopes []string) Source {
+// NewSource returns new token source for token with given name from storage.
+func NewSource(log logger, client *http.Client, storage Storage, name string, secretKey []byte, clientID, ssoSecret string, callbackURL string, scopes []string) Source {
 	sso := goesi.NewSSOAuthenticatorV2(client, clientID, ssoSecret, callbackURL, scopes)
 	return &source{
+		name:    name,
 		storage: storage,
 		sso:     sso,
 	}
 }
 
+// NewSources returns token source for every token in storage.
+func NewSources(log logger, client *http.Client, storage Storage, secretKey []byte, clientID, ssoSecret string, callbackURL string, scopes []string) ([]Source, error) {
+	names, err := storage.Names()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list tokens")
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no tokens in storage, login first")
+	}
+
+	var out []Source
+	for _, name := range names {
+		out = append(out, NewSource(log, client, storage, name, secretKey, clientID, ssoSecret, callbackURL, scopes))
+	}
+	return out, nil
+}
+
+func (s *source) Name() string {
+	return s.name
+}
+
 func (s *source) Token() (*oauth2.Token, error) {
 	ts, err := s.TokenSource()
 	if err != nil {
@@ -45,7 +69,7 @@ func (s *source) Token() (*oauth2.Token, error) {
 	}
 
 	// Save token.
-	err = s.storage.Write(*newToken)
+	err = s.storage.Write(s.name, *newToken)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to save refreshed token")
 	}
@@ -54,7 +78,7 @@ func (s *source) Token() (*oauth2.Token, error) {
 }
 
 func (s *source) TokenSource() (oauth2.TokenSource, error) {
-	token, err := s.storage.Read()
+	token, err := s.storage.Read(s.name)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to read token")
 	}
@@ -3,19 +3,29 @@ package token
 import (
 	"encoding/gob"
 	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 )
 
-// Storage is interface for accessing token data.
+// DefaultName is name under which token from single-character
+// auth files (before multiple characters were supported) is available.
+const DefaultName = "default"
+
+// Storage is interface for accessing token data. Tokens are stored
+// by name, usually the name of the character they belong to.
 type Storage interface {
-	Read() (oauth2.Token, error)
-	Write(oauth2.Token) error
+	Read(name string) (oauth2.Token, error)
+	Write(name string, token oauth2.Token) error
+	Names() ([]string, error)
 }
 
 type fileStorage struct {
 	filename string
+	mu       sync.Mutex
 }
 
 // NewFileStorage returns token storage in file.
@@ -25,29 +35,105 @@ func NewFileStorage(filename string) Storage {
 	}
 }
 
-func (fs *fileStorage) Read() (oauth2.Token, error) {
-	var out oauth2.Token
-	f, err := os.Open(fs.filename)
+func (fs *fileStorage) Read(name string) (oauth2.Token, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	tokens, err := fs.read()
 	if err != nil {
-		return out, errors.Wrapf(err, "unable to open file for reading: %s", fs.filename)
+		return oauth2.Token{}, err
 	}
-	defer f.Close()
-	dec := gob.NewDecoder(f)
-	err = dec.Decode(&out)
+	token, ok := tokens[name]
+	if !ok {
+		return oauth2.Token{}, errors.Errorf("no token for: %s in file: %s", name, fs.filename)
+	}
+	return token, nil
+}
+
+// Write adds or replaces token with given name, other tokens are kept.
+func (fs *fileStorage) Write(name string, token oauth2.Token) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	tokens, err := fs.read()
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return err
+	}
+	if tokens == nil {
+		tokens = make(map[string]oauth2.Token)
+	}
+	if old, ok := tokens[name]; ok && sameToken(old, token) {
+		return nil
+	}
+	tokens[name] = token
+
+	f, err := os.CreateTemp(filepath.Dir(fs.filename), filepath.Base(fs.filename)+".*")
 	if err != nil {
-		return out, errors.Wrap(err, "error decoding auth file")
+		return errors.Wrapf(err, "unable to create temporary file for: %s", fs.filename)
 	}
+	defer os.Remove(f.Name())
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(tokens)
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "error encoding auth file")
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write file: %s", f.Name())
+	}
+	return errors.Wrapf(os.Rename(f.Name(), fs.filename), "unable to replace file: %s", fs.filename)
+}
+
+// sameToken reports whether storing b in place of a would change nothing,
+// so refreshes returning the cached token do not rewrite the file.
+func sameToken(a, b oauth2.Token) bool {
+	return a.AccessToken == b.AccessToken &&
+		a.TokenType == b.TokenType &&
+		a.RefreshToken == b.RefreshToken &&
+		a.Expiry.Equal(b.Expiry)
+}
 
+// Names returns sorted names of all stored tokens.
+func (fs *fileStorage) Names() ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	tokens, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for name := range tokens {
+		out = append(out, name)
+	}
+	sort.Strings(out)
 	return out, nil
 }
 
-// Write truncates the file and replaces it with supplied token.
-func (fs *fileStorage) Write(token oauth2.Token) error {
-	f, err := os.Create(fs.filename)
+// read decodes all tokens from the file, fs.mu must be held.
+func (fs *fileStorage) read() (map[string]oauth2.Token, error) {
+	f, err := os.Open(fs.filename)
 	if err != nil {
-		return errors.Wrapf(err, "unable to create file: %s", fs.filename)
+		return nil, errors.Wrapf(err, "unable to open file for reading: %s", fs.filename)
 	}
 	defer f.Close()
-	enc := gob.NewEncoder(f)
-	return errors.Wrap(enc.Encode(token), "error encoding auth file")
+
+	var tokens map[string]oauth2.Token
+	err = gob.NewDecoder(f).Decode(&tokens)
+	if err == nil {
+		return tokens, nil
+	}
+
+	// Auth files written by older versions contain single token.
+	_, seekErr := f.Seek(0, 0)
+	if seekErr != nil {
+		return nil, errors.Wrap(seekErr, "error decoding auth file")
+	}
+	var token oauth2.Token
+	legacyErr := gob.NewDecoder(f).Decode(&token)
+	if legacyErr != nil {
+		return nil, errors.Wrap(err, "error decoding auth file")
+	}
+	return map[string]oauth2.Token{DefaultName: token}, nil
 }